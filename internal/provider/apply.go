@@ -0,0 +1,266 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Apply modes accepted by the apply_mode provider attribute.
+const (
+	applyModeImmediate = "immediate"
+	applyModeDeferred  = "deferred"
+)
+
+const (
+	filterApplyEndpoint = "firewall/filter/apply"
+
+	// In deferred mode a reconfigure is debounced: it runs once no further
+	// request for the same endpoint has arrived for applyBatchWindow, but
+	// never later than applyBatchMaxWait after the first one was queued.
+	applyBatchWindow  = 2 * time.Second
+	applyBatchMaxWait = 30 * time.Second
+
+	// OPNsense reverts a savepoint 60 seconds after the apply unless it is
+	// confirmed. The reachability check gives up safeApplyCheckDeadline
+	// after the apply and the confirmation safeApplyConfirmDeadline after
+	// it, so neither can reach a revision that was already reverted. Each
+	// probe is bounded by safeApplyProbeTimeout, even when timeout_seconds
+	// is longer or disabled.
+	safeApplyCheckDeadline   = 35 * time.Second
+	safeApplyConfirmDeadline = 50 * time.Second
+	safeApplyProbeTimeout    = 5 * time.Second
+	safeApplyCheckInterval   = 3 * time.Second
+)
+
+// applyChanges runs Reconfigure for endpoint and reports a failure in diags,
+// as an error or, when apply_errors_as_warnings is set, as a warning.
+func (c *Client) applyChanges(ctx context.Context, endpoint string, diags *diag.Diagnostics) {
+	err := c.Reconfigure(ctx, endpoint)
+	if err == nil {
+		return
+	}
+
+	summary := "Apply Error"
+	detail := fmt.Sprintf("The change was saved, but applying it failed, so the running configuration "+
+		"no longer matches the saved one: %s", err)
+	if c.applyErrorsAsWarnings {
+		diags.AddWarning(summary, detail)
+		return
+	}
+	diags.AddError(summary, detail)
+}
+
+// applyBatch collects the callers waiting for one reconfigure of an endpoint.
+type applyBatch struct {
+	started time.Time
+	last    time.Time
+	waiters int
+	done    chan struct{}
+	err     error
+
+	// now is closed to flush without waiting for the endpoint to be quiet.
+	now     chan struct{}
+	nowOnce sync.Once
+}
+
+// applyQueue deduplicates reconfigure calls per endpoint in deferred mode.
+type applyQueue struct {
+	mu      sync.Mutex
+	pending map[string]*applyBatch
+}
+
+// deferApply queues a reconfigure of endpoint and waits for the batch it
+// joined to be flushed. Every caller of the batch gets the same result.
+//
+// The plugin protocol has no call marking the end of an apply, and a
+// resource counts as applied as soon as its Create, Update or Delete
+// returns. A single flush after the last change could therefore not report
+// its failure on any resource, so batches are debounced instead and every
+// caller waits for its batch. When a caller is cancelled, e.g. because
+// Terraform was interrupted, the batch is flushed right away and the caller
+// still reports its result, so no queued change is left unapplied.
+func (c *Client) deferApply(ctx context.Context, endpoint string) error {
+	now := time.Now()
+
+	c.applies.mu.Lock()
+	if c.applies.pending == nil {
+		c.applies.pending = map[string]*applyBatch{}
+	}
+	b, ok := c.applies.pending[endpoint]
+	if !ok {
+		b = &applyBatch{started: now, done: make(chan struct{}), now: make(chan struct{})}
+		c.applies.pending[endpoint] = b
+		go c.flushWhenQuiet(context.WithoutCancel(ctx), endpoint, b)
+	}
+	b.last = now
+	b.waiters++
+	c.applies.mu.Unlock()
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		b.nowOnce.Do(func() { close(b.now) })
+		<-b.done
+	}
+	return b.err
+}
+
+// flushWhenQuiet runs the reconfigure for b once its endpoint has been quiet
+// for applyBatchWindow, or as soon as b.now is closed. Requests arriving
+// after the flush starts open a new batch, so no change is left unapplied.
+func (c *Client) flushWhenQuiet(ctx context.Context, endpoint string, b *applyBatch) {
+	for {
+		c.applies.mu.Lock()
+		wait := applyBatchWindow - time.Since(b.last)
+		if remaining := applyBatchMaxWait - time.Since(b.started); remaining < wait {
+			wait = remaining
+		}
+		select {
+		case <-b.now:
+			wait = 0
+		default:
+		}
+		if wait <= 0 {
+			delete(c.applies.pending, endpoint)
+			c.applies.mu.Unlock()
+			break
+		}
+		c.applies.mu.Unlock()
+
+		select {
+		case <-time.After(wait):
+		case <-b.now:
+		}
+	}
+
+	tflog.Debug(ctx, "Flushing deferred reconfigure", map[string]any{
+		"endpoint": endpoint,
+		"changes":  b.waiters,
+	})
+
+	b.err = c.runApply(ctx, endpoint)
+	close(b.done)
+}
+
+// runApply performs the reconfigure call for endpoint and, in an HA pair,
+// synchronizes the backup node afterwards.
+func (c *Client) runApply(ctx context.Context, endpoint string) error {
+	var err error
+	if endpoint == filterApplyEndpoint {
+		err = c.applyFilter(ctx)
+	} else {
+		err = c.postApply(ctx, endpoint)
+	}
+	if err != nil || c.ha == nil {
+		return err
+	}
+	return c.syncHA(ctx, endpoint)
+}
+
+// postApply calls an apply/reconfigure endpoint and checks the "status"
+// field of its response, e.g. {"status":"ok"}.
+func (c *Client) postApply(ctx context.Context, endpoint string) error {
+	var body json.RawMessage
+	if err := c.Post(ctx, endpoint, nil, &body); err != nil {
+		return err
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(trimmed, &result); err != nil {
+		return nil
+	}
+	status, ok := result["status"].(string)
+	if !ok || strings.EqualFold(strings.TrimSpace(status), "ok") {
+		return nil
+	}
+	return &ApplyError{Endpoint: endpoint, Status: strings.TrimSpace(status)}
+}
+
+// applyFilter applies pending firewall filter changes. With safe apply
+// enabled it takes a savepoint first and only confirms the new ruleset once
+// the API is still reachable; otherwise OPNsense rolls back on its own.
+func (c *Client) applyFilter(ctx context.Context) error {
+	if !c.safeApply {
+		return c.postApply(ctx, filterApplyEndpoint)
+	}
+
+	var savepoint struct {
+		Revision string `json:"revision"`
+	}
+	if err := c.Post(ctx, "firewall/filter/savepoint", nil, &savepoint); err != nil {
+		return fmt.Errorf("unable to create filter savepoint: %w", err)
+	}
+	if savepoint.Revision == "" {
+		return errors.New("unable to create filter savepoint: API returned no revision")
+	}
+
+	tflog.Debug(ctx, "Applying firewall filter with rollback", map[string]any{"revision": savepoint.Revision})
+
+	// The rollback timer starts with the apply call
+	applied := time.Now()
+	if err := c.postApply(ctx, filterApplyEndpoint+"/"+savepoint.Revision); err != nil {
+		return fmt.Errorf("unable to apply filter changes: %w", err)
+	}
+
+	checkCtx, cancelCheck := context.WithDeadline(ctx, applied.Add(safeApplyCheckDeadline))
+	err := c.checkReachable(checkCtx)
+	cancelCheck()
+	if err != nil {
+		return fmt.Errorf("OPNsense API unreachable after applying filter revision %s; "+
+			"the firewall will roll back to the savepoint automatically: %w", savepoint.Revision, err)
+	}
+
+	confirmCtx, cancelConfirm := context.WithDeadline(ctx, applied.Add(safeApplyConfirmDeadline))
+	defer cancelConfirm()
+	if err := c.Post(confirmCtx, "firewall/filter/cancelRollback/"+savepoint.Revision, nil, nil); err != nil {
+		return fmt.Errorf("unable to confirm filter revision %s; "+
+			"the firewall will roll back to the savepoint automatically: %w", savepoint.Revision, err)
+	}
+	return nil
+}
+
+// checkReachable polls a cheap filter endpoint over new connections until
+// it answers or ctx, which must carry a deadline, expires.
+func (c *Client) checkReachable(ctx context.Context) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		// A single attempt each: DoRequest's retries could outlast the
+		// rollback timer.
+		var resp *http.Response
+		probeCtx, cancel := context.WithTimeout(ctx, safeApplyProbeTimeout)
+		_, resp, err = c.doOnceWith(probeCtx, c.probeClient, http.MethodGet, "firewall/filter/getRule", nil)
+		cancel()
+		if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		if err == nil {
+			err = &APIError{Method: http.MethodGet, Endpoint: "firewall/filter/getRule", StatusCode: resp.StatusCode}
+		}
+
+		tflog.Debug(ctx, "OPNsense API not reachable yet", map[string]any{
+			"attempt": attempt,
+			"error":   err.Error(),
+		})
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(safeApplyCheckInterval):
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// Capabilities describes the OPNsense release and plugins found when the
+// provider was configured.
+type Capabilities struct {
+	// Known is false when the firmware API could not be queried; nothing is
+	// then rejected up front.
+	Known   bool
+	Product string
+	Version string
+	Series  string
+	Plugins []string
+}
+
+// subsystem is an API area used by one or more resources. It is available
+// from CoreSince on, or on older releases when Plugin is installed.
+type subsystem struct {
+	Name      string
+	CoreSince string
+	Plugin    string
+}
+
+var (
+	subsystemFilter    = subsystem{Name: "firewall filter rules", CoreSince: "24.1", Plugin: "os-firewall"}
+	subsystemDNat      = subsystem{Name: "destination NAT", CoreSince: "26.1"}
+	subsystemSNat      = subsystem{Name: "source NAT", CoreSince: "26.1"}
+	subsystemKea       = subsystem{Name: "Kea DHCP", CoreSince: "24.1", Plugin: "os-kea-dhcp"}
+	subsystemWireguard = subsystem{Name: "WireGuard", CoreSince: "24.1", Plugin: "os-wireguard"}
+)
+
+// detectCapabilities reads the release and installed plugins from
+// core/firmware/info, falling back to core/firmware/status for the version.
+func (c *Client) detectCapabilities(ctx context.Context) (Capabilities, error) {
+	var info map[string]interface{}
+	if err := c.Get(ctx, "core/firmware/info", &info); err != nil {
+		return Capabilities{}, err
+	}
+
+	caps := Capabilities{Known: true}
+	readProduct(&caps, info)
+	if product, ok := info["product"].(map[string]interface{}); ok {
+		readProduct(&caps, product)
+	}
+
+	if caps.Version == "" {
+		var status map[string]interface{}
+		if err := c.Get(ctx, "core/firmware/status", &status); err == nil {
+			readProduct(&caps, status)
+			if product, ok := status["product"].(map[string]interface{}); ok {
+				readProduct(&caps, product)
+			}
+		}
+	}
+	if caps.Version == "" {
+		return Capabilities{}, fmt.Errorf("core/firmware/info did not report a product version")
+	}
+	if caps.Series == "" {
+		caps.Series = versionSeries(caps.Version)
+	}
+
+	plugins, _ := info["plugin"].([]interface{})
+	for _, raw := range plugins {
+		plugin, ok := raw.(map[string]interface{})
+		if !ok || !boolValue(plugin["installed"]) {
+			continue
+		}
+		if name := stringValue(plugin["name"]); name != "" {
+			caps.Plugins = append(caps.Plugins, name)
+		}
+	}
+	sort.Strings(caps.Plugins)
+
+	return caps, nil
+}
+
+func readProduct(caps *Capabilities, m map[string]interface{}) {
+	if v := stringValue(m["product_name"]); v != "" {
+		caps.Product = v
+	}
+	if v := stringValue(m["product_version"]); v != "" {
+		caps.Version = v
+	}
+	if v := stringValue(m["product_series"]); v != "" {
+		caps.Series = v
+	}
+}
+
+// HasPlugin reports whether the named plugin (e.g. "os-wireguard") is
+// installed.
+func (caps Capabilities) HasPlugin(name string) bool {
+	i := sort.SearchStrings(caps.Plugins, name)
+	return i < len(caps.Plugins) && caps.Plugins[i] == name
+}
+
+// supports reports whether s is available; unknown capabilities are
+// assumed to support everything.
+func (caps Capabilities) supports(s subsystem) bool {
+	if !caps.Known {
+		return true
+	}
+	if s.CoreSince != "" && compareVersions(caps.Version, s.CoreSince) >= 0 {
+		return true
+	}
+	return s.Plugin != "" && caps.HasPlugin(s.Plugin)
+}
+
+// requireSubsystem adds an error to diags when s is not available on the
+// configured firewall.
+func (c *Client) requireSubsystem(s subsystem, diags *diag.Diagnostics) {
+	if c == nil || c.Capabilities.supports(s) {
+		return
+	}
+
+	detail := fmt.Sprintf("This resource needs %s, which is not available on OPNsense %s.", s.Name, c.Capabilities.Version)
+	switch {
+	case s.CoreSince != "" && s.Plugin != "":
+		detail += fmt.Sprintf(" Upgrade to %s or later, or install the %s plugin.", s.CoreSince, s.Plugin)
+	case s.CoreSince != "":
+		detail += fmt.Sprintf(" Upgrade to %s or later.", s.CoreSince)
+	case s.Plugin != "":
+		detail += fmt.Sprintf(" Install the %s plugin.", s.Plugin)
+	}
+	diags.AddError("OPNsense Feature Not Available", detail)
+}
+
+// versionSeries returns the "26.1" part of a version such as "26.1.2_3".
+func versionSeries(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' })
+}
+
+// compareVersions compares dotted OPNsense versions numerically, ignoring
+// package revisions ("_3") and suffixes ("-amd64"). It returns -1, 0 or 1.
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(version string) []int {
+	if i := strings.IndexAny(version, "_- "); i >= 0 {
+		version = version[:i]
+	}
+	var parts []int
+	for _, p := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
@@ -0,0 +1,311 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Cassette modes accepted by the cassette_mode provider attribute.
+const (
+	cassetteModeRecord = "record"
+	cassetteModeReplay = "replay"
+)
+
+// cassetteRedacted replaces credentials in recorded interactions.
+const cassetteRedacted = "REDACTED"
+
+// cassettePlayedFile lists, one per line, the interaction files a replay has
+// used. Terraform starts a new provider process for every command and often
+// for plan and apply, so progress has to outlive the Cassette.
+const cassettePlayedFile = "played"
+
+// cassetteInteraction is one request/response pair, stored as
+// <dir>/<sequence>.json.
+type cassetteInteraction struct {
+	// Node is "backup" for requests to the backup node of an HA pair.
+	Node        string          `json:"node,omitempty"`
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	RequestBody json.RawMessage `json:"request_body,omitempty"`
+	StatusCode  int             `json:"status_code"`
+	// The response body is kept as JSON when it is JSON, which is easier
+	// to read and edit, and as text otherwise.
+	ResponseBody json.RawMessage `json:"response_body,omitempty"`
+	ResponseText string          `json:"response_text,omitempty"`
+
+	file string
+}
+
+// Cassette records the API traffic of a Client to a directory, or replays a
+// recording instead of talking to OPNsense. API keys and secrets never
+// reach the disk: the Authorization header is not recorded and the
+// credentials are replaced with REDACTED wherever else they appear.
+type Cassette struct {
+	dir  string
+	mode string
+
+	mu           sync.Mutex
+	secrets      []string
+	next         int
+	interactions []cassetteInteraction
+	played       []bool
+}
+
+// OpenCassette prepares dir for mode "record" or "replay". Recording
+// appends to the interactions already in dir; replaying needs at least one
+// and skips those listed in the played file by earlier provider processes.
+// Delete that file to replay a cassette from the start.
+func OpenCassette(dir, mode string) (*Cassette, error) {
+	if dir == "" {
+		return nil, errors.New("cassette directory must not be empty")
+	}
+	c := &Cassette{dir: dir, mode: mode}
+
+	switch mode {
+	case cassetteModeRecord:
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("unable to create cassette directory: %w", err)
+		}
+	case cassetteModeReplay:
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", mode)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	last := 0
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read cassette: %w", err)
+		}
+		var i cassetteInteraction
+		if err := json.Unmarshal(raw, &i); err != nil {
+			return nil, fmt.Errorf("unable to parse cassette file %s: %w", file, err)
+		}
+		// Files are indented for editing; requests are matched compacted.
+		i.RequestBody = cassetteJSON(i.RequestBody)
+		i.file = filepath.Base(file)
+		c.interactions = append(c.interactions, i)
+
+		// Interactions may have been deleted by hand; never reuse a number.
+		if n, err := strconv.Atoi(strings.TrimSuffix(i.file, ".json")); err == nil && n > last {
+			last = n
+		}
+	}
+	c.next = last + 1
+	c.played = make([]bool, len(c.interactions))
+
+	if mode == cassetteModeReplay {
+		if len(c.interactions) == 0 {
+			return nil, fmt.Errorf("cassette directory %s holds no recorded interactions", dir)
+		}
+		if err := c.loadPlayed(); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// loadPlayed marks the interactions listed in the played file.
+func (c *Cassette) loadPlayed() error {
+	raw, err := os.ReadFile(filepath.Join(c.dir, cassettePlayedFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read cassette progress: %w", err)
+	}
+	played := map[string]bool{}
+	for _, line := range strings.Split(string(raw), "\n") {
+		played[strings.TrimSpace(line)] = true
+	}
+	for n, i := range c.interactions {
+		c.played[n] = played[i.file]
+	}
+	return nil
+}
+
+// markPlayed records in the played file that interaction n has been used.
+func (c *Cassette) markPlayed(n int) error {
+	c.played[n] = true
+	f, err := os.OpenFile(filepath.Join(c.dir, cassettePlayedFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("unable to save cassette progress: %w", err)
+	}
+	_, err = fmt.Fprintln(f, c.interactions[n].file)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("unable to save cassette progress: %w", err)
+	}
+	return nil
+}
+
+// addSecrets registers values to redact, such as an API key and secret.
+func (c *Cassette) addSecrets(secrets ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range secrets {
+		if s != "" {
+			c.secrets = append(c.secrets, s)
+		}
+	}
+}
+
+func (c *Cassette) redact(b []byte) []byte {
+	for _, s := range c.secrets {
+		b = bytes.ReplaceAll(b, []byte(s), []byte(cassetteRedacted))
+	}
+	return b
+}
+
+// transport wraps base so requests of node are recorded or replayed.
+func (c *Cassette) transport(node string, base http.RoundTripper) http.RoundTripper {
+	return &cassetteTransport{cassette: c, node: node, base: base}
+}
+
+type cassetteTransport struct {
+	cassette *Cassette
+	node     string
+	base     http.RoundTripper
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	c := t.cassette
+	c.mu.Lock()
+	key := cassetteInteraction{
+		Node:        t.node,
+		Method:      req.Method,
+		Path:        string(c.redact([]byte(req.URL.RequestURI()))),
+		RequestBody: cassetteJSON(c.redact(reqBody)),
+	}
+	c.mu.Unlock()
+
+	if c.mode == cassetteModeReplay {
+		return c.replay(req, key)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	if err := c.record(key, resp.StatusCode, respBody); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// record writes an interaction to the next file of the cassette.
+func (c *Cassette) record(i cassetteInteraction, status int, body []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i.StatusCode = status
+	body = c.redact(body)
+	if raw := cassetteJSON(body); raw != nil {
+		i.ResponseBody = raw
+	} else {
+		i.ResponseText = string(body)
+	}
+
+	out, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(c.dir, fmt.Sprintf("%05d.json", c.next))
+	if err := os.WriteFile(file, append(out, '\n'), 0o600); err != nil {
+		return fmt.Errorf("unable to write cassette: %w", err)
+	}
+	c.next++
+	return nil
+}
+
+// replay answers req with the first interaction not played yet, by this or
+// an earlier provider process, that has the same node, method, path and
+// request body. Matching on content rather than position keeps replays
+// working when Terraform runs resources in a different order.
+func (c *Cassette) replay(req *http.Request, key cassetteInteraction) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for n, i := range c.interactions {
+		if c.played[n] || i.Node != key.Node || i.Method != key.Method || i.Path != key.Path ||
+			!bytes.Equal(i.RequestBody, key.RequestBody) {
+			continue
+		}
+		if err := c.markPlayed(n); err != nil {
+			return nil, err
+		}
+
+		body := []byte(i.ResponseText)
+		header := http.Header{}
+		if i.ResponseBody != nil {
+			body = i.ResponseBody
+			header.Set("Content-Type", "application/json")
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", i.StatusCode, http.StatusText(i.StatusCode)),
+			StatusCode:    i.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	detail := ""
+	if key.RequestBody != nil {
+		detail = " with body " + string(key.RequestBody)
+	}
+	return nil, fmt.Errorf("cassette %s has no unplayed response for %s %s%s", c.dir, key.Method, key.Path, detail)
+}
+
+// cassetteJSON returns b compacted when it is a JSON document, nil
+// otherwise.
+func cassetteJSON(b []byte) json.RawMessage {
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) == 0 || !json.Valid(trimmed) {
+		return nil
+	}
+	var out bytes.Buffer
+	if err := json.Compact(&out, trimmed); err != nil {
+		return nil
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,203 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// cassetteSession runs the same API calls against whatever the client talks
+// to, so a recording can be checked against its replay.
+func cassetteSession(t *testing.T, client *Client) (string, map[string]interface{}) {
+	t.Helper()
+	ctx := context.Background()
+
+	var created map[string]interface{}
+	err := client.Post(ctx, "firewall/category/addItem", map[string]interface{}{
+		// Credentials in a request body are redacted too.
+		"category": map[string]interface{}{"name": "web-" + fakeAPISecret, "color": "ff0000"},
+	}, &created)
+	if err != nil {
+		t.Fatalf("addItem: %v", err)
+	}
+	uuid := stringValue(created["uuid"])
+	item, err := client.GetItem(ctx, "firewall/category/getItem/"+uuid, "category")
+	if err != nil {
+		t.Fatalf("getItem: %v", err)
+	}
+	return uuid, item
+}
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	f := newFakeOPNsense(t)
+	dir := filepath.Join(t.TempDir(), "cassette")
+
+	recorder, err := OpenCassette(dir, cassetteModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(ClientConfig{Host: f.Server.URL, ApiKey: f.APIKey, ApiSecret: f.APISecret, Cassette: recorder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordedUUID, recordedItem := cassetteSession(t, client)
+	if recordedItem == nil {
+		t.Fatal("recorded getItem returned no category")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("recorded %d interactions, want 2", len(files))
+	}
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{fakeAPIKey, fakeAPISecret} {
+			if strings.Contains(string(raw), secret) {
+				t.Errorf("%s contains the credential %q:\n%s", file, secret, raw)
+			}
+		}
+	}
+
+	// Replays must not need OPNsense, nor the real credentials.
+	f.Server.Close()
+	player, err := OpenCassette(dir, cassetteModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err = NewClient(ClientConfig{Host: f.Server.URL, ApiKey: "other-key", ApiSecret: fakeAPISecret, Cassette: player, RetryWaitMin: 1, RetryWaitMax: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayedUUID, replayedItem := cassetteSession(t, client)
+	if replayedUUID != recordedUUID || stringValue(replayedItem["color"]) != stringValue(recordedItem["color"]) {
+		t.Errorf("replay = %s %v, want %s %v", replayedUUID, replayedItem, recordedUUID, recordedItem)
+	}
+	if got := stringValue(replayedItem["name"]); got != "web-"+cassetteRedacted {
+		t.Errorf("replayed name = %q, want the secret redacted", got)
+	}
+	if left := player.unplayed(); len(left) != 0 {
+		t.Errorf("unplayed interactions: %v", left)
+	}
+
+	// A request that was never recorded fails instead of reaching the network.
+	if _, err := client.GetItem(context.Background(), "firewall/category/getItem/"+recordedUUID, "category"); err == nil ||
+		!strings.Contains(err.Error(), "no unplayed response") {
+		t.Errorf("unrecorded request error = %v, want a cassette miss", err)
+	}
+}
+
+func TestOpenCassetteReplayNeedsRecording(t *testing.T) {
+	if _, err := OpenCassette(t.TempDir(), cassetteModeReplay); err == nil {
+		t.Error("replaying an empty directory succeeded, want an error")
+	}
+	if _, err := OpenCassette(t.TempDir(), "rewind"); err == nil {
+		t.Error("unknown mode succeeded, want an error")
+	}
+}
+
+// cassetteClient returns a client of a cassette opened on dir.
+func cassetteClient(t *testing.T, f *fakeOPNsense, dir, mode string) (*Client, *Cassette) {
+	t.Helper()
+	cassette, err := OpenCassette(dir, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(ClientConfig{Host: f.Server.URL, ApiKey: f.APIKey, ApiSecret: f.APISecret, Cassette: cassette, RetryWaitMin: 1, RetryWaitMax: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, cassette
+}
+
+func TestCassetteReplayAcrossProcesses(t *testing.T) {
+	f := newFakeOPNsense(t)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	// Create, then update and read back, as terraform apply and a later
+	// terraform apply would.
+	recorder, _ := cassetteClient(t, f, dir, cassetteModeRecord)
+	uuid, _ := cassetteSession(t, recorder)
+	update := map[string]interface{}{"category": map[string]interface{}{"color": "00ff00"}}
+	if err := recorder.Post(ctx, "firewall/category/setItem/"+uuid, update, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorder.GetItem(ctx, "firewall/category/getItem/"+uuid, "category"); err != nil {
+		t.Fatal(err)
+	}
+	f.Server.Close()
+
+	first, _ := cassetteClient(t, f, dir, cassetteModeReplay)
+	cassetteSession(t, first)
+
+	// A new provider process continues where the previous one stopped
+	// instead of answering the read with the first recorded response.
+	second, cassette := cassetteClient(t, f, dir, cassetteModeReplay)
+	if err := second.Post(ctx, "firewall/category/setItem/"+uuid, update, nil); err != nil {
+		t.Fatalf("setItem: %v", err)
+	}
+	item, err := second.GetItem(ctx, "firewall/category/getItem/"+uuid, "category")
+	if err != nil {
+		t.Fatalf("getItem: %v", err)
+	}
+	if got := stringValue(item["color"]); got != "00ff00" {
+		t.Errorf("second process read color %q, want the updated 00ff00", got)
+	}
+	if left := cassette.unplayed(); len(left) != 0 {
+		t.Errorf("unplayed interactions: %v", left)
+	}
+
+	// Removing the progress file starts the replay over.
+	if err := os.Remove(filepath.Join(dir, cassettePlayedFile)); err != nil {
+		t.Fatal(err)
+	}
+	if _, cassette := cassetteClient(t, f, dir, cassetteModeReplay); len(cassette.unplayed()) != 4 {
+		t.Errorf("unplayed after reset = %v, want all 4 interactions", cassette.unplayed())
+	}
+}
+
+func TestCassetteRecordAfterDeletedInteraction(t *testing.T) {
+	f := newFakeOPNsense(t)
+	dir := t.TempDir()
+
+	recorder, _ := cassetteClient(t, f, dir, cassetteModeRecord)
+	cassetteSession(t, recorder)
+	kept, err := os.ReadFile(filepath.Join(dir, "00002.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "00001.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder, _ = cassetteClient(t, f, dir, cassetteModeRecord)
+	if _, err := recorder.GetItem(context.Background(), "firewall/category/getItem/00000000-0000-4000-8000-000000000000", "category"); err != nil {
+		t.Fatal(err)
+	}
+
+	if after, err := os.ReadFile(filepath.Join(dir, "00002.json")); err != nil || string(after) != string(kept) {
+		t.Errorf("00002.json was overwritten: %s, %v", after, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "00003.json")); err != nil {
+		t.Errorf("new interaction not recorded as 00003.json: %v", err)
+	}
+}
+
+// unplayed lists the interactions a replay has not used. Leftovers usually
+// mean the provider no longer sends a request it used to.
+func (c *Cassette) unplayed() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var left []string
+	for n, i := range c.interactions {
+		if !c.played[n] {
+			left = append(left, strings.TrimSpace(i.Method+" "+i.Path))
+		}
+	}
+	return left
+}
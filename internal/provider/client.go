@@ -0,0 +1,456 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// ClientConfig holds the settings used to build a Client.
+type ClientConfig struct {
+	Host      string
+	ApiKey    string
+	ApiSecret string
+	TLS       TLSConfig
+	Timeout   time.Duration
+	// SafeApply applies filter changes through a savepoint that OPNsense
+	// rolls back unless the provider can still reach the API afterwards.
+	SafeApply bool
+	// ApplyMode is "immediate" (the default) to reconfigure after every
+	// change, or "deferred" to batch reconfigures per service.
+	ApplyMode string
+	// ApplyErrorsAsWarnings reports failed apply/reconfigure calls as
+	// warnings instead of errors.
+	ApplyErrorsAsWarnings bool
+	// MaxRetries is the number of times a transient failure is retried;
+	// RetryWaitMin and RetryWaitMax bound the backoff between attempts.
+	// Zero values select the defaults, a negative MaxRetries disables
+	// retries.
+	MaxRetries   int
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+	// RequestsPerSecond limits the request rate; zero means unlimited.
+	RequestsPerSecond float64
+	// MaxConcurrentWrites bounds the mutating requests in flight per
+	// WriteLockScope ("global" or "subsystem"). Reads are not limited.
+	MaxConcurrentWrites int
+	WriteLockScope      string
+	// HA, when set, synchronizes every apply to the backup node of a CARP
+	// pair.
+	HA *HAConfig
+	// Cassette, when set, records the API traffic or replays a recording
+	// instead of contacting OPNsense.
+	Cassette *Cassette
+
+	// cassetteNode tells the requests of an HA backup client apart.
+	cassetteNode string
+}
+
+// Client represents the OPNsense API client
+type Client struct {
+	Host                  string
+	ApiKey                string
+	ApiSecret             string
+	Capabilities          Capabilities
+	timeout               time.Duration
+	safeApply             bool
+	applyMode             string
+	applies               applyQueue
+	client                *http.Client
+	probeClient           *http.Client
+	applyErrorsAsWarnings bool
+	maxRetries            int
+	retryWaitMin          time.Duration
+	retryWaitMax          time.Duration
+	limiter               *rateLimiter
+	writes                *writeLimiter
+	ha                    *haSync
+
+	// allocations serializes tunnel address allocation for WireGuard peers.
+	allocations sync.Mutex
+	// serverPeers serializes read-modify-writes of WireGuard server peer
+	// lists.
+	serverPeers sync.Mutex
+}
+
+// NewClient creates a new OPNsense API client
+func NewClient(cfg ClientConfig) (*Client, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("host must not be empty")
+	}
+	switch cfg.ApplyMode {
+	case "":
+		cfg.ApplyMode = applyModeImmediate
+	case applyModeImmediate, applyModeDeferred:
+	default:
+		return nil, fmt.Errorf("unknown apply mode %q", cfg.ApplyMode)
+	}
+
+	switch cfg.WriteLockScope {
+	case "", writeScopeGlobal, writeScopeSubsystem:
+	default:
+		return nil, fmt.Errorf("unknown write lock scope %q", cfg.WriteLockScope)
+	}
+	switch {
+	case cfg.MaxRetries == 0:
+		cfg.MaxRetries = defaultMaxRetries
+	case cfg.MaxRetries < 0:
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryWaitMin <= 0 {
+		cfg.RetryWaitMin = defaultRetryWaitMin
+	}
+	if cfg.RetryWaitMax <= 0 {
+		cfg.RetryWaitMax = defaultRetryWaitMax
+	}
+	if cfg.RetryWaitMax < cfg.RetryWaitMin {
+		cfg.RetryWaitMax = cfg.RetryWaitMin
+	}
+
+	tlsConfig, err := buildTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	tr := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}
+
+	httpClient := &http.Client{
+		Transport: tr,
+		Timeout:   0, // Timeouts are applied per request in DoRequest
+	}
+
+	// The safe apply reachability check must not reuse a kept-alive
+	// connection: it can survive a ruleset that blocks new ones.
+	probeTransport := tr.Clone()
+	probeTransport.DisableKeepAlives = true
+	probeClient := &http.Client{Transport: probeTransport}
+
+	if cfg.Cassette != nil {
+		cfg.Cassette.addSecrets(cfg.ApiKey, cfg.ApiSecret)
+		httpClient.Transport = cfg.Cassette.transport(cfg.cassetteNode, tr)
+		probeClient.Transport = cfg.Cassette.transport(cfg.cassetteNode, probeTransport)
+	}
+
+	c := &Client{
+		Host:                  strings.TrimRight(cfg.Host, "/"),
+		ApiKey:                cfg.ApiKey,
+		ApiSecret:             cfg.ApiSecret,
+		timeout:               cfg.Timeout,
+		safeApply:             cfg.SafeApply,
+		applyMode:             cfg.ApplyMode,
+		applyErrorsAsWarnings: cfg.ApplyErrorsAsWarnings,
+		maxRetries:            cfg.MaxRetries,
+		retryWaitMin:          cfg.RetryWaitMin,
+		retryWaitMax:          cfg.RetryWaitMax,
+		limiter:               newRateLimiter(cfg.RequestsPerSecond),
+		writes:                newWriteLimiter(cfg.MaxConcurrentWrites, cfg.WriteLockScope),
+		client:                httpClient,
+		probeClient:           probeClient,
+	}
+
+	if cfg.HA != nil {
+		c.ha, err = newHASync(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// APIError is returned when the OPNsense API answers with a non-2xx status.
+type APIError struct {
+	Method     string
+	Endpoint   string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s %s returned status %d", e.Method, e.Endpoint, e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s returned status %d: %s", e.Method, e.Endpoint, e.StatusCode, e.Body)
+}
+
+// IsNotFound reports whether err is an APIError for a missing object.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// DoRequest performs an HTTP request to the OPNsense API and returns the raw
+// response body. endpoint is relative to /api/, e.g. "firewall/filter/apply".
+// Transient failures are retried with backoff, see shouldRetry. Mutating
+// requests hold a write slot for all of their attempts.
+func (c *Client) DoRequest(ctx context.Context, method, endpoint string, body []byte) ([]byte, error) {
+	endpoint = strings.TrimLeft(endpoint, "/")
+
+	if isMutating(method, endpoint) {
+		release, err := c.writes.Acquire(ctx, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		respBody, resp, err := c.doOnce(ctx, method, endpoint, body)
+		var retryable bool
+		if err != nil {
+			retryable = shouldRetry(method, endpoint, 0, err)
+		} else {
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				return respBody, nil
+			}
+			err = &APIError{
+				Method:     method,
+				Endpoint:   endpoint,
+				StatusCode: resp.StatusCode,
+				Body:       strings.TrimSpace(string(respBody)),
+			}
+			retryable = shouldRetry(method, endpoint, resp.StatusCode, nil)
+		}
+
+		if !retryable || attempt >= c.maxRetries || ctx.Err() != nil {
+			return nil, err
+		}
+
+		wait := retryBackoff(attempt+1, c.retryWaitMin, c.retryWaitMax, resp)
+		tflog.Warn(ctx, "Retrying API request", map[string]any{
+			"method":   method,
+			"endpoint": endpoint,
+			"attempt":  attempt + 1,
+			"wait":     wait.String(),
+			"error":    err.Error(),
+		})
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// doOnce performs a single attempt of a request, applying the per-request
+// timeout. The returned response has its body already read and closed.
+func (c *Client) doOnce(ctx context.Context, method, endpoint string, body []byte) ([]byte, *http.Response, error) {
+	return c.doOnceWith(ctx, c.client, method, endpoint, body)
+}
+
+// doOnceWith is doOnce sending the request through httpClient.
+func (c *Client) doOnceWith(ctx context.Context, httpClient *http.Client, method, endpoint string, body []byte) ([]byte, *http.Response, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	url := fmt.Sprintf("%s/api/%s", c.Host, endpoint)
+
+	tflog.Debug(ctx, "Making API request", map[string]any{
+		"method":   method,
+		"endpoint": endpoint,
+		"url":      url,
+	})
+
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.SetBasicAuth(c.ApiKey, c.ApiSecret)
+	req.Header.Set("Accept", "application/json")
+	// OPNsense rejects an empty body sent as JSON, so only label real payloads
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp, fmt.Errorf("error reading response: %w", err)
+	}
+
+	tflog.Debug(ctx, "API response", map[string]any{
+		"endpoint":    endpoint,
+		"status_code": resp.StatusCode,
+		"body":        string(respBody),
+	})
+
+	return respBody, resp, nil
+}
+
+// Get performs a GET request and decodes the JSON response into out.
+// out may be nil when only the status matters.
+func (c *Client) Get(ctx context.Context, endpoint string, out interface{}) error {
+	body, err := c.DoRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(body, out)
+}
+
+// Post encodes in as JSON, performs a POST request and decodes the JSON
+// response into out. Either in or out may be nil. Payloads rejected by
+// OPNsense are reported as a *ValidationError.
+func (c *Client) Post(ctx context.Context, endpoint string, in, out interface{}) error {
+	var payload []byte
+	if in != nil {
+		var err error
+		payload, err = json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("error encoding request: %w", err)
+		}
+	}
+
+	body, err := c.DoRequest(ctx, http.MethodPost, endpoint, payload)
+	if err != nil {
+		return err
+	}
+	if verr := parseValidationError(endpoint, body); verr != nil {
+		return verr
+	}
+	return decodeResponse(body, out)
+}
+
+// GetItem fetches a single model object and returns the map stored under key,
+// e.g. GetItem(ctx, "firewall/filter/getRule/"+id, "rule"). OPNsense reports a
+// missing object with a 404, an empty array or a body without key; all of
+// these return a nil map and no error.
+func (c *Client) GetItem(ctx context.Context, endpoint, key string) (map[string]interface{}, error) {
+	var body json.RawMessage
+	err := c.Get(ctx, endpoint, &body)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, nil
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(trimmed, &result); err != nil {
+		return nil, fmt.Errorf("unable to parse response: %w (raw response: %s)", err, string(body))
+	}
+
+	item, ok := result[key].(map[string]interface{})
+	if !ok || len(item) == 0 {
+		return nil, nil
+	}
+	return item, nil
+}
+
+// Search calls a bootgrid search endpoint such as "firewall/filter/searchRule"
+// and returns every matching row. params are sent alongside the paging
+// fields, e.g. {"searchPhrase": "web"}.
+func (c *Client) Search(ctx context.Context, endpoint string, params map[string]interface{}) ([]map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"current":      1,
+		"rowCount":     -1,
+		"searchPhrase": "",
+	}
+	for k, v := range params {
+		payload[k] = v
+	}
+
+	var result struct {
+		Rows []map[string]interface{} `json:"rows"`
+	}
+	if err := c.Post(ctx, endpoint, payload, &result); err != nil {
+		return nil, err
+	}
+	return result.Rows, nil
+}
+
+// FindByDescription returns the UUID of the only item whose description is
+// exactly description, e.g. FindByDescription(ctx, "firewall/d_nat/search_rule",
+// "firewall/d_nat/get_rule/", "rule", "HTTPS", "NAT rule"). Search rows are
+// only a prefilter as searchPhrase matches substrings of any column. kind
+// names the items in errors.
+func (c *Client) FindByDescription(ctx context.Context, search, get, key, description, kind string) (string, error) {
+	rows, err := c.Search(ctx, search, map[string]interface{}{"searchPhrase": description})
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	for _, row := range rows {
+		uuid := stringValue(row["uuid"])
+		if uuid == "" {
+			continue
+		}
+		item, err := c.GetItem(ctx, get+uuid, key)
+		if err != nil {
+			return "", err
+		}
+		if item != nil && stringValue(item["description"]) == description {
+			matches = append(matches, uuid)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no %s has the UUID or description %q", kind, description)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%d %ss have the description %q (%s); import by UUID instead",
+			len(matches), kind, description, strings.Join(matches, ", "))
+	}
+}
+
+// Reconfigure triggers an apply/reconfigure endpoint such as
+// "firewall/filter/apply" or "kea/service/reconfigure". In deferred mode
+// concurrent calls for the same endpoint are merged into a single one.
+func (c *Client) Reconfigure(ctx context.Context, endpoint string) error {
+	if c.applyMode == applyModeDeferred {
+		return c.deferApply(ctx, endpoint)
+	}
+	return c.runApply(ctx, endpoint)
+}
+
+func decodeResponse(body []byte, out interface{}) error {
+	if out == nil {
+		return nil
+	}
+	if raw, ok := out.(*json.RawMessage); ok {
+		*raw = append((*raw)[:0], body...)
+		return nil
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return errors.New("API returned empty response")
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("unable to parse response: %w (raw response: %s)", err, string(body))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// CredentialSources lists the places API credentials can be read from
+// besides the provider configuration and environment.
+type CredentialSources struct {
+	// ApiKeyFile and ApiSecretFile hold the key and the secret on their own.
+	ApiKeyFile    string
+	ApiSecretFile string
+	// CredentialsFile is the apikey.txt file OPNsense offers for download
+	// when an API key is created ("key=..." and "secret=..." lines).
+	CredentialsFile string
+	// Command is run without a shell and must print a JSON object with
+	// "api_key" and "api_secret" (or "key" and "secret") on stdout.
+	Command []string
+}
+
+// Resolve fills in the key and secret still empty in apiKey and apiSecret
+// from the first source that provides them: the individual files, the
+// apikey.txt file, then the command.
+func (s CredentialSources) Resolve(ctx context.Context, apiKey, apiSecret string) (string, string, error) {
+	if apiKey == "" && s.ApiKeyFile != "" {
+		key, err := readSecretFile(s.ApiKeyFile)
+		if err != nil {
+			return "", "", fmt.Errorf("unable to read API key file: %w", err)
+		}
+		apiKey = key
+	}
+	if apiSecret == "" && s.ApiSecretFile != "" {
+		secret, err := readSecretFile(s.ApiSecretFile)
+		if err != nil {
+			return "", "", fmt.Errorf("unable to read API secret file: %w", err)
+		}
+		apiSecret = secret
+	}
+
+	if (apiKey == "" || apiSecret == "") && s.CredentialsFile != "" {
+		key, secret, err := readAPIKeyTxt(s.CredentialsFile)
+		if err != nil {
+			return "", "", fmt.Errorf("unable to read credentials file: %w", err)
+		}
+		apiKey, apiSecret = firstNonEmpty(apiKey, key), firstNonEmpty(apiSecret, secret)
+	}
+
+	if (apiKey == "" || apiSecret == "") && len(s.Command) > 0 {
+		key, secret, err := runCredentialsCommand(ctx, s.Command)
+		if err != nil {
+			return "", "", err
+		}
+		apiKey, apiSecret = firstNonEmpty(apiKey, key), firstNonEmpty(apiSecret, secret)
+	}
+
+	return apiKey, apiSecret, nil
+}
+
+func readSecretFile(name string) (string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readAPIKeyTxt parses the apikey.txt file generated by OPNsense:
+//
+//	key=...
+//	secret=...
+func readAPIKeyTxt(name string) (string, string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	var key, secret string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "key":
+			key = strings.TrimSpace(value)
+		case "secret":
+			secret = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+	if key == "" || secret == "" {
+		return "", "", errors.New("file does not contain key= and secret= lines")
+	}
+	return key, secret, nil
+}
+
+func runCredentialsCommand(ctx context.Context, command []string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("credentials command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var out struct {
+		ApiKey    string `json:"api_key"`
+		ApiSecret string `json:"api_secret"`
+		Key       string `json:"key"`
+		Secret    string `json:"secret"`
+	}
+	// The output is not included in the error: it may contain the secret.
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return "", "", errors.New("credentials command did not print a JSON object")
+	}
+	return firstNonEmpty(out.ApiKey, out.Key), firstNonEmpty(out.ApiSecret, out.Secret), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
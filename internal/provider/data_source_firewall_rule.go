@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
@@ -22,12 +23,22 @@ type FirewallRuleDataSource struct {
 type FirewallRuleDataSourceModel struct {
 	ID          types.String `tfsdk:"id"`
 	Description types.String `tfsdk:"description"`
+	Sequence    types.Int64  `tfsdk:"sequence"`
 	Interface   types.String `tfsdk:"interface"`
+	Direction   types.String `tfsdk:"direction"`
+	IPProtocol  types.String `tfsdk:"ip_protocol"`
 	Protocol    types.String `tfsdk:"protocol"`
 	SourceNet   types.String `tfsdk:"source_net"`
+	SourcePort  types.String `tfsdk:"source_port"`
 	DestNet     types.String `tfsdk:"destination_net"`
+	DestPort    types.String `tfsdk:"destination_port"`
 	Action      types.String `tfsdk:"action"`
 	Enabled     types.Bool   `tfsdk:"enabled"`
+	Log         types.Bool   `tfsdk:"log"`
+	Quick       types.Bool   `tfsdk:"quick"`
+	Invert      types.Bool   `tfsdk:"invert"`
+	SourceNot   types.Bool   `tfsdk:"source_not"`
+	Categories  types.List   `tfsdk:"categories"`
 }
 
 func (d *FirewallRuleDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -36,19 +47,36 @@ func (d *FirewallRuleDataSource) Metadata(ctx context.Context, req datasource.Me
 
 func (d *FirewallRuleDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		MarkdownDescription: "Fetches information about an OPNsense firewall rule",
+		MarkdownDescription: "Fetches information about an OPNsense firewall rule. " +
+			"Look the rule up by `id`, by exact `description`, or by `interface` and `sequence`.",
 
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
 				MarkdownDescription: "Rule UUID",
-				Required:            true,
+				Optional:            true,
+				Computed:            true,
 			},
 			"description": schema.StringAttribute{
-				MarkdownDescription: "Description of the firewall rule",
+				MarkdownDescription: "Description of the firewall rule. When set without `id`, the rule with exactly this description is returned",
+				Optional:            true,
+				Computed:            true,
+			},
+			"sequence": schema.Int64Attribute{
+				MarkdownDescription: "Rule sequence. Can be combined with `interface` to look up a rule",
+				Optional:            true,
 				Computed:            true,
 			},
 			"interface": schema.StringAttribute{
-				MarkdownDescription: "Interface name",
+				MarkdownDescription: "Interface name. Can be combined with `sequence` or `description` to look up a rule",
+				Optional:            true,
+				Computed:            true,
+			},
+			"direction": schema.StringAttribute{
+				MarkdownDescription: "Direction of traffic",
+				Computed:            true,
+			},
+			"ip_protocol": schema.StringAttribute{
+				MarkdownDescription: "IP protocol version",
 				Computed:            true,
 			},
 			"protocol": schema.StringAttribute{
@@ -59,10 +87,18 @@ func (d *FirewallRuleDataSource) Schema(ctx context.Context, req datasource.Sche
 				MarkdownDescription: "Source network or IP address",
 				Computed:            true,
 			},
+			"source_port": schema.StringAttribute{
+				MarkdownDescription: "Source port or port range",
+				Computed:            true,
+			},
 			"destination_net": schema.StringAttribute{
 				MarkdownDescription: "Destination network or IP address",
 				Computed:            true,
 			},
+			"destination_port": schema.StringAttribute{
+				MarkdownDescription: "Destination port or port range",
+				Computed:            true,
+			},
 			"action": schema.StringAttribute{
 				MarkdownDescription: "Action to take",
 				Computed:            true,
@@ -71,6 +107,27 @@ func (d *FirewallRuleDataSource) Schema(ctx context.Context, req datasource.Sche
 				MarkdownDescription: "Whether the rule is enabled",
 				Computed:            true,
 			},
+			"log": schema.BoolAttribute{
+				MarkdownDescription: "Whether packets matching this rule are logged",
+				Computed:            true,
+			},
+			"quick": schema.BoolAttribute{
+				MarkdownDescription: "Whether the action is applied immediately on match",
+				Computed:            true,
+			},
+			"invert": schema.BoolAttribute{
+				MarkdownDescription: "Whether the destination match is inverted",
+				Computed:            true,
+			},
+			"source_not": schema.BoolAttribute{
+				MarkdownDescription: "Whether the source match is inverted",
+				Computed:            true,
+			},
+			"categories": schema.ListAttribute{
+				MarkdownDescription: "Category UUIDs assigned to the rule",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
 		},
 	}
 }
@@ -100,8 +157,157 @@ func (d *FirewallRuleDataSource) Read(ctx context.Context, req datasource.ReadRe
 		return
 	}
 
-	// In a real implementation, you would fetch the rule data from the API here
-	// For now, this is a placeholder
+	d.client.requireSubsystem(subsystemFilter, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id := data.ID.ValueString()
+	if id == "" {
+		if data.Description.IsNull() && (data.Interface.IsNull() || data.Sequence.IsNull()) {
+			resp.Diagnostics.AddError(
+				"Missing Firewall Rule Lookup",
+				"Set id, description, or both interface and sequence to select a firewall rule.",
+			)
+			return
+		}
+
+		uuid, err := d.findRule(ctx, data)
+		if err != nil {
+			addClientError(&resp.Diagnostics, "Unable to look up rule", err, nil)
+			return
+		}
+		id = uuid
+	}
+
+	rule, err := d.client.GetItem(ctx, "firewall/filter/getRule/"+id, "rule")
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Unable to read rule", err, nil)
+		return
+	}
+	if rule == nil {
+		resp.Diagnostics.AddError("Firewall Rule Not Found", fmt.Sprintf("No firewall rule with UUID %q exists.", id))
+		return
+	}
+
+	v := parseFirewallRule(rule)
+	data.ID = types.StringValue(id)
+	data.Description = types.StringValue(v.Description)
+	if v.HasSequence {
+		data.Sequence = types.Int64Value(v.Sequence)
+	} else {
+		data.Sequence = types.Int64Null()
+	}
+	data.Interface = types.StringValue(v.Interface)
+	data.Direction = types.StringValue(v.Direction)
+	data.IPProtocol = types.StringValue(v.IPProtocol)
+	data.Protocol = types.StringValue(v.Protocol)
+	data.SourceNet = types.StringValue(v.SourceNet)
+	data.SourcePort = types.StringValue(v.SourcePort)
+	data.DestNet = types.StringValue(v.DestNet)
+	data.DestPort = types.StringValue(v.DestPort)
+	data.Action = types.StringValue(v.Action)
+	data.Enabled = types.BoolValue(v.Enabled)
+	data.Log = types.BoolValue(v.Log)
+	data.Quick = types.BoolValue(v.Quick)
+	data.Invert = types.BoolValue(v.DestinationNot)
+	data.SourceNot = types.BoolValue(v.SourceNot)
+
+	categories, diags := types.ListValueFrom(ctx, types.StringType, v.Categories)
+	resp.Diagnostics.Append(diags...)
+	data.Categories = categories
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
+
+// findRule resolves the lookup attributes to a single rule UUID using
+// searchRule. Candidates are confirmed against getRule because search rows
+// carry display values rather than the stored ones.
+func (d *FirewallRuleDataSource) findRule(ctx context.Context, data FirewallRuleDataSourceModel) (string, error) {
+	params := map[string]interface{}{}
+	if !data.Description.IsNull() {
+		params["searchPhrase"] = data.Description.ValueString()
+	}
+	if !data.Interface.IsNull() {
+		params["interface"] = data.Interface.ValueString()
+	}
+
+	rows, err := d.client.Search(ctx, "firewall/filter/searchRule", params)
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	for _, row := range rows {
+		uuid := stringValue(row["uuid"])
+		if uuid == "" {
+			continue
+		}
+		if !data.Description.IsNull() && stringValue(row["description"]) != data.Description.ValueString() {
+			continue
+		}
+		if !data.Sequence.IsNull() {
+			if sequence, ok := int64Value(row["sequence"]); ok && sequence != data.Sequence.ValueInt64() {
+				continue
+			}
+		}
+
+		rule, err := d.client.GetItem(ctx, "firewall/filter/getRule/"+uuid, "rule")
+		if err != nil {
+			return "", err
+		}
+		if rule == nil || !firewallRuleMatches(parseFirewallRule(rule), data) {
+			continue
+		}
+		matches = append(matches, uuid)
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no firewall rule matches %s", describeRuleLookup(data))
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%d firewall rules match %s (%s); narrow the lookup or use id",
+			len(matches), describeRuleLookup(data), strings.Join(matches, ", "))
+	}
+}
+
+func firewallRuleMatches(rule firewallRuleValues, data FirewallRuleDataSourceModel) bool {
+	if !data.Description.IsNull() && rule.Description != data.Description.ValueString() {
+		return false
+	}
+	if !data.Sequence.IsNull() && (!rule.HasSequence || rule.Sequence != data.Sequence.ValueInt64()) {
+		return false
+	}
+	if !data.Interface.IsNull() {
+		found := false
+		for _, iface := range strings.Split(rule.Interface, ",") {
+			if strings.EqualFold(iface, data.Interface.ValueString()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func describeRuleLookup(data FirewallRuleDataSourceModel) string {
+	var parts []string
+	if !data.Description.IsNull() {
+		parts = append(parts, fmt.Sprintf("description %q", data.Description.ValueString()))
+	}
+	if !data.Interface.IsNull() {
+		parts = append(parts, fmt.Sprintf("interface %q", data.Interface.ValueString()))
+	}
+	if !data.Sequence.IsNull() {
+		parts = append(parts, fmt.Sprintf("sequence %d", data.Sequence.ValueInt64()))
+	}
+	return strings.Join(parts, ", ")
+}
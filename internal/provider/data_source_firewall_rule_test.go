@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestFirewallRuleDataSource(t *testing.T) {
+	f := newFakeOPNsense(t)
+	f.Put("filter.rule", map[string]interface{}{
+		"description":      "Allow HTTPS",
+		"interface":        "wan",
+		"protocol":         "TCP",
+		"destination_port": "443",
+		"sequence":         "10",
+	})
+	f.Put("filter.rule", map[string]interface{}{
+		"description": "Allow HTTPS to mail",
+		"interface":   "lan",
+		"protocol":    "TCP",
+		"sequence":    "20",
+	})
+
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: `
+data "opnsense_firewall_rule" "by_description" {
+  description = "Allow HTTPS"
+}
+
+data "opnsense_firewall_rule" "by_sequence" {
+  interface = "lan"
+  sequence  = 20
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("data.opnsense_firewall_rule.by_description", "destination_port", "443"),
+				resource.TestCheckResourceAttr("data.opnsense_firewall_rule.by_description", "interface", "wan"),
+				resource.TestCheckResourceAttr("data.opnsense_firewall_rule.by_sequence", "description", "Allow HTTPS to mail"),
+			),
+		},
+		resource.TestStep{
+			Config: `
+data "opnsense_firewall_rule" "missing" {
+  description = "Allow SSH"
+}
+`,
+			ExpectError: regexp.MustCompile(`(?i)no\s+firewall\s+rule`),
+		},
+	)
+}
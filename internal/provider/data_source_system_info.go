@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &SystemInfoDataSource{}
+
+func NewSystemInfoDataSource() datasource.DataSource {
+	return &SystemInfoDataSource{}
+}
+
+type SystemInfoDataSource struct {
+	client *Client
+}
+
+type SystemInfoDataSourceModel struct {
+	ID          types.String `tfsdk:"id"`
+	Product     types.String `tfsdk:"product"`
+	Version     types.String `tfsdk:"version"`
+	Series      types.String `tfsdk:"series"`
+	Hostname    types.String `tfsdk:"hostname"`
+	Plugins     types.List   `tfsdk:"plugins"`
+	CPUType     types.String `tfsdk:"cpu_type"`
+	LoadAverage types.String `tfsdk:"load_average"`
+	MemoryTotal types.Int64  `tfsdk:"memory_total_bytes"`
+	MemoryUsed  types.Int64  `tfsdk:"memory_used_bytes"`
+	Uptime      types.String `tfsdk:"uptime"`
+}
+
+func (d *SystemInfoDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_system_info"
+}
+
+func (d *SystemInfoDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Reads the firmware version, installed plugins and current resource usage of the OPNsense firewall",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Host URL of the firewall",
+				Computed:            true,
+			},
+			"product": schema.StringAttribute{
+				MarkdownDescription: "Product name (e.g., 'OPNsense')",
+				Computed:            true,
+			},
+			"version": schema.StringAttribute{
+				MarkdownDescription: "Firmware version (e.g., '26.1.2')",
+				Computed:            true,
+			},
+			"series": schema.StringAttribute{
+				MarkdownDescription: "Product series (e.g., '26.1')",
+				Computed:            true,
+			},
+			"hostname": schema.StringAttribute{
+				MarkdownDescription: "Fully qualified hostname of the firewall",
+				Computed:            true,
+			},
+			"plugins": schema.ListAttribute{
+				MarkdownDescription: "Names of the installed plugins (e.g., 'os-wireguard'), sorted",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+			"cpu_type": schema.StringAttribute{
+				MarkdownDescription: "CPU model and core count",
+				Computed:            true,
+			},
+			"load_average": schema.StringAttribute{
+				MarkdownDescription: "Load averages over 1, 5 and 15 minutes",
+				Computed:            true,
+			},
+			"memory_total_bytes": schema.Int64Attribute{
+				MarkdownDescription: "Physical memory in bytes",
+				Computed:            true,
+			},
+			"memory_used_bytes": schema.Int64Attribute{
+				MarkdownDescription: "Memory in use in bytes",
+				Computed:            true,
+			},
+			"uptime": schema.StringAttribute{
+				MarkdownDescription: "Time since the last boot as reported by OPNsense",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *SystemInfoDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *Client, got: %T.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *SystemInfoDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data SystemInfoDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	caps, err := d.client.detectCapabilities(ctx)
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Unable to read firmware information", err, nil)
+		return
+	}
+
+	data.ID = types.StringValue(d.client.Host)
+	data.Product = types.StringValue(caps.Product)
+	data.Version = types.StringValue(caps.Version)
+	data.Series = types.StringValue(caps.Series)
+
+	plugins, diags := types.ListValueFrom(ctx, types.StringType, append([]string{}, caps.Plugins...))
+	resp.Diagnostics.Append(diags...)
+	data.Plugins = plugins
+
+	// The diagnostics endpoints need their own privileges; report what is
+	// readable and leave the rest null.
+	data.Hostname = types.StringNull()
+	var sysInfo map[string]interface{}
+	if err := d.client.Get(ctx, "diagnostics/system/system_information", &sysInfo); err != nil {
+		resp.Diagnostics.AddWarning("Unable to read system information", err.Error())
+	} else if name := stringValue(sysInfo["name"]); name != "" {
+		data.Hostname = types.StringValue(name)
+	}
+
+	data.CPUType = types.StringNull()
+	var cpuType []interface{}
+	if err := d.client.Get(ctx, "diagnostics/cpu_usage/getCPUType", &cpuType); err != nil {
+		resp.Diagnostics.AddWarning("Unable to read CPU type", err.Error())
+	} else if len(cpuType) > 0 {
+		data.CPUType = types.StringValue(strings.TrimSpace(stringValue(cpuType[0])))
+	}
+
+	data.MemoryTotal = types.Int64Null()
+	data.MemoryUsed = types.Int64Null()
+	var resources struct {
+		Memory map[string]interface{} `json:"memory"`
+	}
+	if err := d.client.Get(ctx, "diagnostics/system/system_resources", &resources); err != nil {
+		resp.Diagnostics.AddWarning("Unable to read memory usage", err.Error())
+	} else {
+		if total, ok := int64Value(resources.Memory["total"]); ok {
+			data.MemoryTotal = types.Int64Value(total)
+		}
+		if used, ok := int64Value(resources.Memory["used"]); ok {
+			data.MemoryUsed = types.Int64Value(used)
+		}
+	}
+
+	data.Uptime = types.StringNull()
+	data.LoadAverage = types.StringNull()
+	var sysTime map[string]interface{}
+	if err := d.client.Get(ctx, "diagnostics/system/system_time", &sysTime); err != nil {
+		resp.Diagnostics.AddWarning("Unable to read uptime", err.Error())
+	} else {
+		if uptime := stringValue(sysTime["uptime"]); uptime != "" {
+			data.Uptime = types.StringValue(uptime)
+		}
+		if load := stringValue(sysTime["loadavg"]); load != "" {
+			data.LoadAverage = types.StringValue(load)
+		}
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,26 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestSystemInfoDataSource(t *testing.T) {
+	f := newFakeOPNsense(t)
+	f.SetVersion("26.1.2_3", "os-wireguard", "os-acme-client")
+
+	fakeTest(t, f, resource.TestStep{
+		Config: `
+data "opnsense_system_info" "test" {}
+`,
+		Check: resource.ComposeAggregateTestCheckFunc(
+			resource.TestCheckResourceAttr("data.opnsense_system_info.test", "version", "26.1.2_3"),
+			resource.TestCheckResourceAttr("data.opnsense_system_info.test", "series", "26.1"),
+			resource.TestCheckResourceAttr("data.opnsense_system_info.test", "hostname", "fw.example.com"),
+			resource.TestCheckResourceAttr("data.opnsense_system_info.test", "plugins.#", "2"),
+			resource.TestCheckResourceAttr("data.opnsense_system_info.test", "plugins.0", "os-acme-client"),
+			resource.TestCheckResourceAttr("data.opnsense_system_info.test", "memory_total_bytes", "8589934592"),
+		),
+	})
+}
@@ -0,0 +1,222 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &WireguardPeerConfigDataSource{}
+
+func NewWireguardPeerConfigDataSource() datasource.DataSource {
+	return &WireguardPeerConfigDataSource{}
+}
+
+type WireguardPeerConfigDataSource struct {
+	client *Client
+}
+
+type WireguardPeerConfigDataSourceModel struct {
+	ID           types.String `tfsdk:"id"`
+	PeerID       types.String `tfsdk:"peer_id"`
+	ServerID     types.String `tfsdk:"server_id"`
+	Endpoint     types.String `tfsdk:"endpoint"`
+	EndpointPort types.Int64  `tfsdk:"endpoint_port"`
+	PrivateKey   types.String `tfsdk:"private_key"`
+	AllowedIPs   types.List   `tfsdk:"allowed_ips"`
+	DNS          types.String `tfsdk:"dns"`
+	Config       types.String `tfsdk:"config"`
+}
+
+func (d *WireguardPeerConfigDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_wireguard_peer_config"
+}
+
+func (d *WireguardPeerConfigDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Renders a wg-quick client configuration for an `opnsense_wireguard_peer` connecting to an `opnsense_wireguard_server`",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Peer UUID",
+				Computed:            true,
+			},
+			"peer_id": schema.StringAttribute{
+				MarkdownDescription: "UUID of the `opnsense_wireguard_peer` the configuration is for",
+				Required:            true,
+			},
+			"server_id": schema.StringAttribute{
+				MarkdownDescription: "UUID of the `opnsense_wireguard_server` the peer connects to",
+				Required:            true,
+			},
+			"endpoint": schema.StringAttribute{
+				MarkdownDescription: "Public hostname or IP address of the firewall, as reachable by the client",
+				Required:            true,
+			},
+			"endpoint_port": schema.Int64Attribute{
+				MarkdownDescription: "Port the client connects to. Defaults to the server's listen port",
+				Optional:            true,
+			},
+			"private_key": schema.StringAttribute{
+				MarkdownDescription: "The client's private key. OPNsense only knows the public key, so without it the PrivateKey line is left for the user to fill in",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"allowed_ips": schema.ListAttribute{
+				MarkdownDescription: "Networks routed through the tunnel on the client. Defaults to all traffic (0.0.0.0/0, ::/0)",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"dns": schema.StringAttribute{
+				MarkdownDescription: "DNS servers for the client. Defaults to the server's DNS setting",
+				Optional:            true,
+			},
+			"config": schema.StringAttribute{
+				MarkdownDescription: "Rendered client configuration, suitable for wg-quick or the WireGuard apps",
+				Computed:            true,
+				Sensitive:           true,
+			},
+		},
+	}
+}
+
+func (d *WireguardPeerConfigDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *Client, got: %T.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+func (d *WireguardPeerConfigDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data WireguardPeerConfigDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	d.client.requireSubsystem(subsystemWireguard, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	peer, err := d.client.GetItem(ctx, "wireguard/client/get_client/"+data.PeerID.ValueString(), "client")
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Unable to read peer", err, nil)
+		return
+	}
+	if peer == nil {
+		resp.Diagnostics.AddError("WireGuard Peer Not Found", fmt.Sprintf("No WireGuard peer with UUID %q exists.", data.PeerID.ValueString()))
+		return
+	}
+
+	server, err := d.client.GetItem(ctx, "wireguard/server/get_server/"+data.ServerID.ValueString(), "server")
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Unable to read server", err, nil)
+		return
+	}
+	if server == nil {
+		resp.Diagnostics.AddError("WireGuard Server Not Found", fmt.Sprintf("No WireGuard server with UUID %q exists.", data.ServerID.ValueString()))
+		return
+	}
+
+	cfg := wireguardClientConfig{
+		PrivateKey:      data.PrivateKey.ValueString(),
+		Address:         splitList(stringValue(peer["tunneladdress"])),
+		DNS:             splitList(stringValue(server["dns"])),
+		ServerPublicKey: stringValue(server["pubkey"]),
+		PresharedKey:    stringValue(peer["psk"]),
+		Endpoint:        data.Endpoint.ValueString(),
+		AllowedIPs:      []string{"0.0.0.0/0", "::/0"},
+	}
+	cfg.EndpointPort, _ = int64Value(server["port"])
+	if !data.EndpointPort.IsNull() {
+		cfg.EndpointPort = data.EndpointPort.ValueInt64()
+	}
+	cfg.Keepalive, _ = int64Value(peer["keepalive"])
+	if !data.DNS.IsNull() {
+		cfg.DNS = splitList(data.DNS.ValueString())
+	}
+	if !data.AllowedIPs.IsNull() {
+		cfg.AllowedIPs = nil
+		resp.Diagnostics.Append(data.AllowedIPs.ElementsAs(ctx, &cfg.AllowedIPs, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if cfg.ServerPublicKey == "" {
+		resp.Diagnostics.AddError("WireGuard Server Has No Key", "The WireGuard server has no public key yet; generate its keys first.")
+		return
+	}
+	if cfg.EndpointPort == 0 {
+		resp.Diagnostics.AddError("Missing Endpoint Port", "The WireGuard server has no listen port; set endpoint_port.")
+		return
+	}
+
+	data.ID = data.PeerID
+	data.Config = types.StringValue(cfg.render())
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// wireguardClientConfig holds the values of a wg-quick configuration file.
+type wireguardClientConfig struct {
+	PrivateKey      string
+	Address         []string
+	DNS             []string
+	ServerPublicKey string
+	PresharedKey    string
+	Endpoint        string
+	EndpointPort    int64
+	AllowedIPs      []string
+	Keepalive       int64
+}
+
+func (c wireguardClientConfig) render() string {
+	var b strings.Builder
+
+	b.WriteString("[Interface]\n")
+	if c.PrivateKey != "" {
+		fmt.Fprintf(&b, "PrivateKey = %s\n", c.PrivateKey)
+	} else {
+		b.WriteString("# PrivateKey = <client private key>\n")
+	}
+	if len(c.Address) > 0 {
+		fmt.Fprintf(&b, "Address = %s\n", strings.Join(c.Address, ", "))
+	}
+	if len(c.DNS) > 0 {
+		fmt.Fprintf(&b, "DNS = %s\n", strings.Join(c.DNS, ", "))
+	}
+
+	b.WriteString("\n[Peer]\n")
+	fmt.Fprintf(&b, "PublicKey = %s\n", c.ServerPublicKey)
+	if c.PresharedKey != "" {
+		fmt.Fprintf(&b, "PresharedKey = %s\n", c.PresharedKey)
+	}
+	fmt.Fprintf(&b, "Endpoint = %s\n", net.JoinHostPort(strings.Trim(c.Endpoint, "[]"), strconv.FormatInt(c.EndpointPort, 10)))
+	if len(c.AllowedIPs) > 0 {
+		fmt.Fprintf(&b, "AllowedIPs = %s\n", strings.Join(c.AllowedIPs, ", "))
+	}
+	if c.Keepalive > 0 {
+		fmt.Fprintf(&b, "PersistentKeepalive = %d\n", c.Keepalive)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestWireguardPeerConfigDataSource(t *testing.T) {
+	f := newFakeOPNsense(t)
+
+	fakeTest(t, f, resource.TestStep{
+		Config: `
+resource "opnsense_wireguard_server" "wg0" {
+  name           = "wg0"
+  listen_port    = 51820
+  tunnel_address = "10.20.30.1/24"
+  dns            = "10.20.30.1"
+}
+
+resource "opnsense_wireguard_peer" "laptop" {
+  name        = "laptop"
+  public_key  = "` + testPeerPublicKey + `"
+  allowed_ips = "10.20.30.2/32"
+  keepalive   = 25
+}
+
+data "opnsense_wireguard_peer_config" "laptop" {
+  peer_id     = opnsense_wireguard_peer.laptop.id
+  server_id   = opnsense_wireguard_server.wg0.id
+  endpoint    = "vpn.example.com"
+  allowed_ips = ["10.0.0.0/8"]
+}
+`,
+		Check: func(s *terraform.State) error {
+			config := s.RootModule().Resources["data.opnsense_wireguard_peer_config.laptop"].Primary.Attributes["config"]
+			serverKey := s.RootModule().Resources["opnsense_wireguard_server.wg0"].Primary.Attributes["public_key"]
+			for _, want := range []string{
+				"# PrivateKey = <client private key>",
+				"Address = 10.20.30.2/32",
+				"DNS = 10.20.30.1",
+				"PublicKey = " + serverKey,
+				"Endpoint = vpn.example.com:51820",
+				"AllowedIPs = 10.0.0.0/8",
+				"PersistentKeepalive = 25",
+			} {
+				if !strings.Contains(config, want+"\n") {
+					return fmt.Errorf("config lacks %q:\n%s", want, config)
+				}
+			}
+			return nil
+		},
+	})
+}
+
+func TestWireguardClientConfigRender(t *testing.T) {
+	cfg := wireguardClientConfig{
+		PrivateKey:      "cHJpdmF0ZQ==",
+		Address:         []string{"10.20.30.2/32", "fd00::2/128"},
+		ServerPublicKey: "c2VydmVy",
+		PresharedKey:    "cHNr",
+		Endpoint:        "2001:db8::1",
+		EndpointPort:    51820,
+		AllowedIPs:      []string{"0.0.0.0/0", "::/0"},
+	}
+
+	want := `[Interface]
+PrivateKey = cHJpdmF0ZQ==
+Address = 10.20.30.2/32, fd00::2/128
+
+[Peer]
+PublicKey = c2VydmVy
+PresharedKey = cHNr
+Endpoint = [2001:db8::1]:51820
+AllowedIPs = 0.0.0.0/0, ::/0
+`
+	if got := cfg.render(); got != want {
+		t.Errorf("render() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// ValidationError is returned when OPNsense accepts a request but rejects
+// its payload, either as {"result":"failed","validations":{...}} or as an
+// array of error objects.
+type ValidationError struct {
+	Endpoint string
+	// Validations maps the OPNsense field name (e.g. "rule.destination_port")
+	// to the messages reported for it.
+	Validations map[string][]string
+	// Messages holds errors that are not tied to a particular field.
+	Messages []string
+}
+
+func (e *ValidationError) Error() string {
+	var parts []string
+	for _, field := range e.fields() {
+		parts = append(parts, fmt.Sprintf("%s: %s", field, strings.Join(e.Validations[field], ", ")))
+	}
+	parts = append(parts, e.Messages...)
+	return fmt.Sprintf("%s validation failed: %s", e.Endpoint, strings.Join(parts, "; "))
+}
+
+func (e *ValidationError) fields() []string {
+	fields := make([]string, 0, len(e.Validations))
+	for field := range e.Validations {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// ApplyError is returned when an apply/reconfigure endpoint answers with a
+// status other than "ok".
+type ApplyError struct {
+	Endpoint string
+	Status   string
+}
+
+func (e *ApplyError) Error() string {
+	if e.Status == "" {
+		return fmt.Sprintf("%s returned an empty status", e.Endpoint)
+	}
+	return fmt.Sprintf("%s returned status %q", e.Endpoint, e.Status)
+}
+
+// parseValidationError inspects the body of a mutating call and returns a
+// ValidationError if OPNsense reported a failure, or nil otherwise.
+func parseValidationError(endpoint string, body []byte) *ValidationError {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil
+	}
+
+	if trimmed[0] == '[' {
+		var items []interface{}
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil
+		}
+
+		verr := &ValidationError{Endpoint: endpoint}
+		if len(items) == 0 {
+			verr.Messages = append(verr.Messages,
+				"API returned an empty array; the endpoint may not exist (is the plugin installed?) or a referenced object is missing")
+			return verr
+		}
+		for _, item := range items {
+			switch v := item.(type) {
+			case map[string]interface{}:
+				if msg, ok := v["message"].(string); ok {
+					verr.Messages = append(verr.Messages, msg)
+				}
+			case string:
+				verr.Messages = append(verr.Messages, v)
+			}
+		}
+		if len(verr.Messages) == 0 {
+			verr.Messages = append(verr.Messages, "API returned unexpected array response: "+string(trimmed))
+		}
+		return verr
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(trimmed, &result); err != nil {
+		return nil
+	}
+
+	status, _ := result["result"].(string)
+	validations, hasValidations := result["validations"].(map[string]interface{})
+	if !strings.EqualFold(status, "failed") && !(hasValidations && len(validations) > 0) {
+		return nil
+	}
+
+	verr := &ValidationError{Endpoint: endpoint, Validations: map[string][]string{}}
+	for field, msgs := range validations {
+		switch v := msgs.(type) {
+		case string:
+			verr.Validations[field] = append(verr.Validations[field], v)
+		case []interface{}:
+			for _, msg := range v {
+				if s, ok := msg.(string); ok {
+					verr.Validations[field] = append(verr.Validations[field], s)
+				}
+			}
+		}
+	}
+	if msg, ok := result["message"].(string); ok && msg != "" {
+		verr.Messages = append(verr.Messages, msg)
+	}
+	if len(verr.Validations) == 0 && len(verr.Messages) == 0 {
+		verr.Messages = append(verr.Messages, "API returned failed status: "+string(trimmed))
+	}
+	return verr
+}
+
+// addClientError appends err to diags. Validation failures whose OPNsense
+// field is listed in fields (field name without the model prefix, mapped to
+// the Terraform attribute name) are reported against that attribute.
+func addClientError(diags *diag.Diagnostics, action string, err error, fields map[string]string) {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		diags.AddError("Client Error", fmt.Sprintf("%s: %s", action, err))
+		return
+	}
+
+	for _, field := range verr.fields() {
+		detail := strings.Join(verr.Validations[field], "\n")
+		if attr, ok := lookupFieldAttribute(field, fields); ok {
+			diags.AddAttributeError(
+				path.Root(attr),
+				"API Validation Error",
+				fmt.Sprintf("%s: OPNsense rejected %s: %s", action, attr, detail),
+			)
+			continue
+		}
+		diags.AddError("API Validation Error", fmt.Sprintf("%s: %s: %s", action, field, detail))
+	}
+	for _, msg := range verr.Messages {
+		diags.AddError("API Validation Error", fmt.Sprintf("%s: %s", action, msg))
+	}
+}
+
+// lookupFieldAttribute resolves an OPNsense field such as "rule.destination_port"
+// or "subnet4.option_data.domain_name" to a Terraform attribute name.
+func lookupFieldAttribute(field string, fields map[string]string) (string, bool) {
+	if fields == nil {
+		return "", false
+	}
+	name := field
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if attr, ok := fields[name]; ok {
+		return attr, true
+	}
+	if i := strings.Index(name, "."); i >= 0 {
+		attr, ok := fields[name[:i]]
+		return attr, ok
+	}
+	return "", false
+}
@@ -0,0 +1,838 @@
+package provider
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeOPNsense is an in-process stand-in for the OPNsense MVC API. It keeps
+// every model in memory and mimics the behaviour the provider relies on:
+// UUIDs returned from add actions, {"result":"failed","validations":{...}}
+// for invalid input, option fields rendered as
+// {"key":{"value":"Label","selected":1}}, "[]" for unknown UUIDs and
+// {"status":"ok"} from apply actions.
+type fakeOPNsense struct {
+	Server *httptest.Server
+	// APIKey and APISecret are the credentials ProviderConfig hands to the
+	// provider; the fake only accepts fakeAPIKey and fakeAPISecret.
+	APIKey    string
+	APISecret string
+	// ProviderSettings are extra provider arguments added by
+	// ProviderConfig, e.g. "safe_apply = true".
+	ProviderSettings string
+
+	mu       sync.Mutex
+	version  string
+	plugins  []string
+	models   map[string]*fakeModel
+	routes   map[string]fakeRoute
+	applies  map[string]int
+	requests []string
+	// failures holds canned responses for the next request to an endpoint.
+	failures map[string][]int
+}
+
+// fakeField describes one field of a fake model item.
+type fakeField struct {
+	// Default is stored when an item is added without the field.
+	Default string
+	// Required fields must not be empty.
+	Required bool
+	// Options makes the field an option field; Multiple allows a
+	// comma-separated selection of several keys.
+	Options  func(f *fakeOPNsense) []string
+	Multiple bool
+	// Lines renders a newline-separated value as an option map of its
+	// entries, like alias content.
+	Lines bool
+	// Nested fields hold an object, like Kea's option_data.
+	Nested bool
+	// Validate returns a validation message for an invalid value.
+	Validate func(f *fakeOPNsense, value string) string
+	// Generate fills an empty value when an item is saved.
+	Generate func(f *fakeOPNsense, item map[string]interface{}) string
+}
+
+// fakeModel is an array field of an OPNsense model, e.g. filter.rules.rule.
+type fakeModel struct {
+	Key    string
+	Fields map[string]fakeField
+	// Unique lists fields whose value must differ between items.
+	Unique []string
+
+	items map[string]map[string]interface{}
+	order []string
+}
+
+type fakeRoute struct {
+	model string
+	op    string
+}
+
+const (
+	fakeAPIKey    = "fake-key"
+	fakeAPISecret = "fake-secret"
+)
+
+// newFakeOPNsense starts a fake OPNsense 26.1 and stops it when the test
+// ends.
+func newFakeOPNsense(t *testing.T) *fakeOPNsense {
+	t.Helper()
+
+	f := &fakeOPNsense{
+		APIKey:    fakeAPIKey,
+		APISecret: fakeAPISecret,
+		version:   "26.1.2",
+		models:    map[string]*fakeModel{},
+		routes:    map[string]fakeRoute{},
+		applies:   map[string]int{},
+		failures:  map[string][]int{},
+	}
+	f.registerModels()
+
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.Server.Close)
+	return f
+}
+
+// SetVersion changes the firmware version and installed plugins reported by
+// core/firmware/info.
+func (f *fakeOPNsense) SetVersion(version string, plugins ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.version = version
+	f.plugins = plugins
+}
+
+// FailNext makes the next requests to endpoint answer with the given HTTP
+// status codes, one per request.
+func (f *fakeOPNsense) FailNext(endpoint string, statusCodes ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[endpoint] = append(f.failures[endpoint], statusCodes...)
+}
+
+// Applies returns how often an apply or reconfigure endpoint was called.
+func (f *fakeOPNsense) Applies(endpoint string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.applies[endpoint]
+}
+
+// Requests returns "METHOD endpoint" for every request received so far.
+func (f *fakeOPNsense) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+// Items returns a copy of the items of model, keyed by UUID.
+func (f *fakeOPNsense) Items(model string) map[string]map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	items := map[string]map[string]interface{}{}
+	for uuid, item := range f.models[model].items {
+		items[uuid] = copyItem(item)
+	}
+	return items
+}
+
+// Item returns a copy of one item, or nil when it does not exist.
+func (f *fakeOPNsense) Item(model, uuid string) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	item, ok := f.models[model].items[uuid]
+	if !ok {
+		return nil
+	}
+	return copyItem(item)
+}
+
+// Put stores an item as if it had been created outside the provider and
+// returns its UUID.
+func (f *fakeOPNsense) Put(model string, fields map[string]interface{}) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m := f.models[model]
+	uuid := newFakeUUID()
+	item := map[string]interface{}{}
+	for name, field := range m.Fields {
+		if field.Default != "" {
+			item[name] = field.Default
+		}
+	}
+	for k, v := range fields {
+		item[k] = v
+	}
+	m.items[uuid] = item
+	m.order = append(m.order, uuid)
+	return uuid
+}
+
+// Update changes fields of an existing item, simulating an edit in the GUI.
+func (f *fakeOPNsense) Update(model, uuid string, fields map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for k, v := range fields {
+		f.models[model].items[uuid][k] = v
+	}
+}
+
+// Remove deletes an item, simulating a deletion in the GUI.
+func (f *fakeOPNsense) Remove(model, uuid string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.models[model].remove(uuid)
+}
+
+// ProviderConfig returns a provider block pointing at the fake.
+func (f *fakeOPNsense) ProviderConfig() string {
+	return fmt.Sprintf(`
+provider "opnsense" {
+  host       = %q
+  api_key    = %q
+  api_secret = %q
+%s
+}
+`, f.Server.URL, f.APIKey, f.APISecret, f.ProviderSettings)
+}
+
+func (f *fakeOPNsense) registerModels() {
+	interfaces := staticOptions("lan", "wan", "opt1")
+	yesNo := func(f *fakeOPNsense, v string) string {
+		if v != "0" && v != "1" {
+			return "Please select a valid option."
+		}
+		return ""
+	}
+
+	f.register("filter.rule", "firewall/filter", "addRule", "getRule", "setRule", "delRule", "searchRule", &fakeModel{
+		Key: "rule",
+		Fields: map[string]fakeField{
+			"enabled":          {Default: "1", Validate: yesNo},
+			"sequence":         {Validate: validateInteger(1, 999999), Generate: nextSequence("filter.rule")},
+			"description":      {},
+			"interface":        {Options: interfaces, Multiple: true},
+			"direction":        {Default: "in", Options: staticOptions("in", "out")},
+			"ipprotocol":       {Default: "inet", Options: staticOptions("inet", "inet6", "inet46")},
+			"protocol":         {Default: "any", Options: staticOptions("any", "TCP", "UDP", "TCP/UDP", "ICMP", "ESP", "GRE")},
+			"source_net":       {Default: "any", Validate: validateNetwork},
+			"source_port":      {Validate: validatePort},
+			"source_not":       {Default: "0", Validate: yesNo},
+			"destination_net":  {Default: "any", Validate: validateNetwork},
+			"destination_port": {Validate: validatePort},
+			"destination_not":  {Default: "0", Validate: yesNo},
+			"action":           {Default: "pass", Options: staticOptions("pass", "block", "reject")},
+			"log":              {Default: "0", Validate: yesNo},
+			"quick":            {Default: "1", Validate: yesNo},
+			"category":         {Options: modelOptions("category"), Multiple: true},
+		},
+	})
+
+	f.register("alias", "firewall/alias", "addItem", "getItem", "setItem", "delItem", "searchItem", &fakeModel{
+		Key: "alias",
+		Fields: map[string]fakeField{
+			"enabled":     {Default: "1", Validate: yesNo},
+			"name":        {Required: true, Validate: validateAliasName},
+			"type":        {Required: true, Options: staticOptions("host", "network", "port", "url", "urltable", "geoip", "networkgroup", "mac", "dynipv6host", "internal", "external")},
+			"content":     {Lines: true},
+			"description": {},
+		},
+		Unique: []string{"name"},
+	})
+
+	f.register("category", "firewall/category", "addItem", "getItem", "setItem", "delItem", "searchItem", &fakeModel{
+		Key: "category",
+		Fields: map[string]fakeField{
+			"name":  {Required: true},
+			"auto":  {Default: "0", Validate: yesNo},
+			"color": {Validate: validateColor},
+		},
+		Unique: []string{"name"},
+	})
+
+	f.register("dnat.rule", "firewall/d_nat", "add_rule", "get_rule", "set_rule", "del_rule", "search_rule", &fakeModel{
+		Key: "rule",
+		Fields: map[string]fakeField{
+			"enabled":     {Default: "1", Validate: yesNo},
+			"interface":   {Required: true, Options: interfaces, Multiple: true},
+			"protocol":    {Default: "tcp", Options: staticOptions("tcp", "udp", "tcp/udp", "icmp")},
+			"source":      {Default: "any", Validate: validateNetwork},
+			"src_port":    {Validate: validatePort},
+			"destination": {Default: "any", Validate: validateNetwork},
+			"dst_port":    {Validate: validatePort},
+			"target":      {Required: true, Validate: validateAddress},
+			"local_port":  {Validate: validatePort},
+			"description": {},
+			"log":         {Default: "0", Validate: yesNo},
+			"pass":        {Default: "0", Validate: yesNo},
+		},
+	})
+
+	f.register("snat.rule", "firewall/source_nat", "add_rule", "get_rule", "set_rule", "del_rule", "search_rule", &fakeModel{
+		Key: "rule",
+		Fields: map[string]fakeField{
+			"enabled":          {Default: "1", Validate: yesNo},
+			"nonat":            {Default: "0", Validate: yesNo},
+			"sequence":         {Validate: validateInteger(1, 999999), Generate: nextSequence("snat.rule")},
+			"interface":        {Required: true, Options: interfaces},
+			"ipprotocol":       {Default: "inet", Options: staticOptions("inet", "inet6")},
+			"protocol":         {Default: "any", Options: staticOptions("any", "tcp", "udp", "tcp/udp", "icmp")},
+			"source_net":       {Required: true, Validate: validateNetwork},
+			"source_port":      {Validate: validatePort},
+			"destination_net":  {Default: "any", Validate: validateNetwork},
+			"destination_port": {Validate: validatePort},
+			"target":           {Validate: validateNetwork},
+			"target_port":      {Validate: validatePort},
+			"staticnatport":    {Default: "0", Validate: yesNo},
+			"poolopts":         {Options: staticOptions("round-robin", "round-robin sticky-address", "random", "random sticky-address", "source-hash", "bitmask")},
+			"description":      {},
+			"log":              {Default: "0", Validate: yesNo},
+		},
+	})
+
+	f.register("kea.subnet", "kea/dhcpv4", "add_subnet", "get_subnet", "set_subnet", "del_subnet", "search_subnet", &fakeModel{
+		Key: "subnet4",
+		Fields: map[string]fakeField{
+			"subnet":                  {Required: true, Validate: validateCIDR},
+			"pools":                   {},
+			"description":             {},
+			"option_data_autocollect": {Default: "1", Validate: yesNo},
+			"option_data":             {Nested: true},
+		},
+		Unique: []string{"subnet"},
+	})
+
+	f.register("kea.reservation", "kea/dhcpv4", "add_reservation", "get_reservation", "set_reservation", "del_reservation", "search_reservation", &fakeModel{
+		Key: "reservation",
+		Fields: map[string]fakeField{
+			"subnet":      {Required: true, Options: modelOptions("kea.subnet")},
+			"ip_address":  {Validate: validateAddress},
+			"hw_address":  {Required: true, Validate: validateMAC},
+			"hostname":    {},
+			"description": {},
+		},
+	})
+
+	f.register("wireguard.server", "wireguard/server", "add_server", "get_server", "set_server", "del_server", "search_server", &fakeModel{
+		Key: "server",
+		Fields: map[string]fakeField{
+			"enabled":       {Default: "1", Validate: yesNo},
+			"name":          {Required: true},
+			"instance":      {Generate: nextSequence("wireguard.server")},
+			"pubkey":        {},
+			"privkey":       {Validate: validateWireguardKey},
+			"port":          {Validate: validateInteger(1, 65535)},
+			"mtu":           {Validate: validateInteger(576, 9300)},
+			"dns":           {},
+			"tunneladdress": {Required: true, Validate: validateCIDRList},
+			"disableroutes": {Default: "0", Validate: yesNo},
+			"gateway":       {},
+			"peers":         {Options: modelOptions("wireguard.client"), Multiple: true},
+		},
+		Unique: []string{"name"},
+	})
+
+	f.register("wireguard.client", "wireguard/client", "add_client", "get_client", "set_client", "del_client", "search_client", &fakeModel{
+		Key: "client",
+		Fields: map[string]fakeField{
+			"enabled":       {Default: "1", Validate: yesNo},
+			"name":          {Required: true},
+			"pubkey":        {Required: true, Validate: validateWireguardKey},
+			"psk":           {Validate: validateWireguardKey},
+			"tunneladdress": {Required: true, Validate: validateCIDRList},
+			"serveraddress": {},
+			"serverport":    {Validate: validateInteger(1, 65535)},
+			"keepalive":     {Validate: validateInteger(1, 86400)},
+		},
+		Unique: []string{"name"},
+	})
+
+	// Public keys are derived from the private key on save, like the
+	// WireGuard model does.
+	server := f.models["wireguard.server"]
+	pubkey := server.Fields["pubkey"]
+	pubkey.Generate = func(f *fakeOPNsense, item map[string]interface{}) string {
+		priv := fakeString(item["privkey"])
+		if priv == "" {
+			priv, _ = fakeKeyPair()
+			item["privkey"] = priv
+		}
+		pub, _ := wireguardPublicKey(priv)
+		return pub
+	}
+	server.Fields["pubkey"] = pubkey
+}
+
+func (f *fakeOPNsense) register(name, controller, add, get, set, del, search string, m *fakeModel) {
+	m.items = map[string]map[string]interface{}{}
+	f.models[name] = m
+	for op, action := range map[string]string{"add": add, "get": get, "set": set, "del": del, "search": search} {
+		f.routes[controller+"/"+action] = fakeRoute{model: name, op: op}
+	}
+}
+
+func (f *fakeOPNsense) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint := strings.TrimPrefix(r.URL.Path, "/api/")
+	action, arg := endpoint, ""
+	if parts := strings.SplitN(endpoint, "/", 4); len(parts) == 4 {
+		action, arg = strings.Join(parts[:3], "/"), parts[3]
+	}
+
+	key, secret, ok := r.BasicAuth()
+	if !ok || key != fakeAPIKey || secret != fakeAPISecret {
+		writeFakeJSON(w, http.StatusUnauthorized, map[string]interface{}{"status": 401, "message": "Authentication Failed"})
+		return
+	}
+
+	var body map[string]interface{}
+	if r.Method == http.MethodPost && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeFakeJSON(w, http.StatusBadRequest, map[string]interface{}{"errorMessage": "Invalid JSON"})
+			return
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+endpoint)
+	if codes := f.failures[action]; len(codes) > 0 {
+		f.failures[action] = codes[1:]
+		writeFakeJSON(w, codes[0], map[string]interface{}{"errorMessage": http.StatusText(codes[0])})
+		return
+	}
+
+	if route, ok := f.routes[action]; ok {
+		status, resp := f.handleModel(r.Method, f.models[route.model], route.op, arg, body)
+		writeFakeJSON(w, status, resp)
+		return
+	}
+
+	switch action {
+	case "core/firmware/info":
+		plugins := []interface{}{}
+		for _, p := range f.plugins {
+			plugins = append(plugins, map[string]interface{}{"name": p, "installed": "1"})
+		}
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{
+			"product": map[string]interface{}{
+				"product_name":    "OPNsense",
+				"product_version": f.version,
+				"product_series":  versionSeries(f.version),
+			},
+			"plugin": plugins,
+		})
+	case "diagnostics/system/system_information":
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{"name": "fw.example.com", "versions": []string{"OPNsense " + f.version}})
+	case "diagnostics/cpu_usage/getCPUType":
+		writeFakeJSON(w, http.StatusOK, []string{"QEMU Virtual CPU version 2.5+ (4 cores, 4 threads)"})
+	case "diagnostics/system/system_resources":
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{
+			"memory": map[string]interface{}{"total": "8589934592", "used": "2147483648"},
+		})
+	case "diagnostics/system/system_time":
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{"uptime": "3 days, 04:05:06", "loadavg": "0.10, 0.20, 0.30"})
+	case "firewall/filter/savepoint":
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{"revision": "1700000000.1234"})
+	case "firewall/filter/cancelRollback":
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+	case "firewall/filter/apply", "firewall/alias/reconfigure", "firewall/apply", "firewall/source_nat/apply",
+		"kea/service/reconfigure", "wireguard/service/reconfigure":
+		f.applies[action]++
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+	case "wireguard/server/key_pair":
+		priv, pub := fakeKeyPair()
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "privkey": priv, "pubkey": pub})
+	default:
+		writeFakeJSON(w, http.StatusNotFound, map[string]interface{}{"errorMessage": "Endpoint not found"})
+	}
+}
+
+func (f *fakeOPNsense) handleModel(method string, m *fakeModel, op, uuid string, body map[string]interface{}) (int, interface{}) {
+	if op != "get" && method != http.MethodPost {
+		return http.StatusMethodNotAllowed, map[string]interface{}{"errorMessage": "Method not allowed"}
+	}
+
+	switch op {
+	case "get":
+		item, ok := m.items[uuid]
+		if !ok {
+			// OPNsense answers an unknown UUID with an empty array.
+			return http.StatusOK, []interface{}{}
+		}
+		return http.StatusOK, map[string]interface{}{m.Key: f.render(m, item)}
+
+	case "add", "set":
+		posted, _ := body[m.Key].(map[string]interface{})
+		if posted == nil {
+			return http.StatusOK, map[string]interface{}{"result": "failed"}
+		}
+
+		item := map[string]interface{}{}
+		if op == "set" {
+			existing, ok := m.items[uuid]
+			if !ok {
+				return http.StatusOK, map[string]interface{}{"result": "failed"}
+			}
+			item = copyItem(existing)
+		} else {
+			for name, field := range m.Fields {
+				if field.Default != "" {
+					item[name] = field.Default
+				}
+			}
+		}
+		for name, v := range posted {
+			if _, known := m.Fields[name]; !known {
+				continue
+			}
+			if s, ok := v.(string); ok {
+				item[name] = strings.TrimSpace(s)
+			} else {
+				item[name] = v
+			}
+		}
+
+		if validations := f.validate(m, uuid, item); len(validations) > 0 {
+			return http.StatusOK, map[string]interface{}{"result": "failed", "validations": validations}
+		}
+		for name, field := range m.Fields {
+			if field.Generate != nil && fakeString(item[name]) == "" {
+				item[name] = field.Generate(f, item)
+			}
+		}
+
+		if op == "add" {
+			uuid = newFakeUUID()
+			m.order = append(m.order, uuid)
+		}
+		m.items[uuid] = item
+		return http.StatusOK, map[string]interface{}{"result": "saved", "uuid": uuid}
+
+	case "del":
+		if _, ok := m.items[uuid]; !ok {
+			return http.StatusOK, map[string]interface{}{"result": "not found"}
+		}
+		m.remove(uuid)
+		return http.StatusOK, map[string]interface{}{"result": "deleted"}
+
+	case "search":
+		phrase := strings.ToLower(fakeString(body["searchPhrase"]))
+		rows := []interface{}{}
+		for _, id := range m.order {
+			item := m.items[id]
+			row := map[string]interface{}{"uuid": id}
+			match := phrase == ""
+			for name, field := range m.Fields {
+				if field.Nested {
+					continue
+				}
+				value := fakeString(item[name])
+				row[name] = value
+				if !match && field.Options == nil && strings.Contains(strings.ToLower(value), phrase) {
+					match = true
+				}
+			}
+			if match {
+				rows = append(rows, row)
+			}
+		}
+		return http.StatusOK, map[string]interface{}{
+			"rows":     rows,
+			"rowCount": len(rows),
+			"total":    len(rows),
+			"current":  1,
+		}
+	}
+	return http.StatusNotFound, map[string]interface{}{"errorMessage": "Endpoint not found"}
+}
+
+// validate checks item against the field definitions of m and returns the
+// messages keyed the way OPNsense does, e.g. "rule.source_port".
+func (f *fakeOPNsense) validate(m *fakeModel, uuid string, item map[string]interface{}) map[string]interface{} {
+	validations := map[string]interface{}{}
+	for name, field := range m.Fields {
+		if field.Nested {
+			if v, ok := item[name]; ok {
+				if _, isMap := v.(map[string]interface{}); !isMap && fakeString(v) != "" {
+					validations[m.Key+"."+name] = "Invalid value."
+				}
+			}
+			continue
+		}
+
+		value := fakeString(item[name])
+		if value == "" {
+			if field.Required {
+				validations[m.Key+"."+name] = "A value is required."
+			}
+			continue
+		}
+		if field.Options != nil {
+			allowed := map[string]bool{}
+			for _, opt := range field.Options(f) {
+				allowed[opt] = true
+			}
+			keys := []string{value}
+			if field.Multiple {
+				keys = strings.Split(value, ",")
+			}
+			for _, k := range keys {
+				if !allowed[strings.TrimSpace(k)] {
+					validations[m.Key+"."+name] = "Option not in list."
+					break
+				}
+			}
+			continue
+		}
+		if field.Validate != nil {
+			if msg := field.Validate(f, value); msg != "" {
+				validations[m.Key+"."+name] = msg
+			}
+		}
+	}
+
+	for _, name := range m.Unique {
+		value := fakeString(item[name])
+		for id, other := range m.items {
+			if id != uuid && value != "" && fakeString(other[name]) == value {
+				validations[m.Key+"."+name] = "Value should be unique."
+			}
+		}
+	}
+	return validations
+}
+
+// render returns an item the way a get action does: option fields as maps
+// of every option with a selected flag, everything else as strings.
+func (f *fakeOPNsense) render(m *fakeModel, item map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	for name, field := range m.Fields {
+		value := item[name]
+		switch {
+		case field.Nested:
+			if value == nil {
+				value = map[string]interface{}{}
+			}
+			out[name] = value
+		case field.Options != nil:
+			selected := map[string]bool{}
+			for _, k := range strings.Split(fakeString(value), ",") {
+				selected[strings.TrimSpace(k)] = true
+			}
+			opts := map[string]interface{}{}
+			for _, opt := range field.Options(f) {
+				opts[opt] = fakeOption(strings.ToUpper(opt), selected[opt])
+			}
+			out[name] = opts
+		case field.Lines:
+			opts := map[string]interface{}{}
+			for _, line := range strings.Split(fakeString(value), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					opts[line] = fakeOption(line, true)
+				}
+			}
+			out[name] = opts
+		default:
+			out[name] = fakeString(value)
+		}
+	}
+	return out
+}
+
+func (m *fakeModel) remove(uuid string) {
+	delete(m.items, uuid)
+	for i, id := range m.order {
+		if id == uuid {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func fakeOption(label string, selected bool) map[string]interface{} {
+	s := 0
+	if selected {
+		s = 1
+	}
+	return map[string]interface{}{"value": label, "selected": s}
+}
+
+func staticOptions(keys ...string) func(*fakeOPNsense) []string {
+	return func(*fakeOPNsense) []string { return keys }
+}
+
+// modelOptions offers the UUIDs of another model, like a ModelRelationField.
+func modelOptions(model string) func(*fakeOPNsense) []string {
+	return func(f *fakeOPNsense) []string {
+		return append([]string(nil), f.models[model].order...)
+	}
+}
+
+// nextSequence numbers new items after the highest existing value.
+func nextSequence(model string) func(*fakeOPNsense, map[string]interface{}) string {
+	return func(f *fakeOPNsense, item map[string]interface{}) string {
+		highest := 0
+		for _, other := range f.models[model].items {
+			for _, field := range []string{"sequence", "instance"} {
+				if n, err := strconv.Atoi(fakeString(other[field])); err == nil && n > highest {
+					highest = n
+				}
+			}
+		}
+		return strconv.Itoa(highest + 1)
+	}
+}
+
+func validateInteger(min, max int) func(*fakeOPNsense, string) string {
+	return func(_ *fakeOPNsense, v string) string {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < min || n > max {
+			return fmt.Sprintf("Value must be between %d and %d.", min, max)
+		}
+		return ""
+	}
+}
+
+func validatePort(_ *fakeOPNsense, v string) string {
+	for _, p := range strings.SplitN(v, "-", 2) {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			if regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`).MatchString(p) {
+				continue
+			}
+			return "Please specify a valid port number, range or alias."
+		}
+		if n < 1 || n > 65535 {
+			return "Please specify a valid port number, range or alias."
+		}
+	}
+	return ""
+}
+
+func validateNetwork(_ *fakeOPNsense, v string) string {
+	if v == "any" || strings.HasSuffix(v, "ip") || strings.HasSuffix(v, "net") ||
+		regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`).MatchString(v) {
+		return ""
+	}
+	if net.ParseIP(v) != nil {
+		return ""
+	}
+	if _, _, err := net.ParseCIDR(v); err == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s is not a valid source IP address or alias.", v)
+}
+
+func validateAddress(_ *fakeOPNsense, v string) string {
+	if net.ParseIP(v) == nil {
+		return "Please specify a valid IP address."
+	}
+	return ""
+}
+
+func validateCIDR(_ *fakeOPNsense, v string) string {
+	if _, _, err := net.ParseCIDR(v); err != nil {
+		return "Please specify a valid network segment or IP address."
+	}
+	return ""
+}
+
+func validateCIDRList(f *fakeOPNsense, v string) string {
+	for _, item := range strings.Split(v, ",") {
+		if msg := validateCIDR(f, strings.TrimSpace(item)); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+func validateMAC(_ *fakeOPNsense, v string) string {
+	if _, err := net.ParseMAC(v); err != nil {
+		return "Please specify a valid MAC address."
+	}
+	return ""
+}
+
+func validateAliasName(_ *fakeOPNsense, v string) string {
+	if !regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]{0,31}$`).MatchString(v) {
+		return "The name must be less than 32 characters long and may only consist of letters, numbers and underscores."
+	}
+	return ""
+}
+
+func validateColor(_ *fakeOPNsense, v string) string {
+	if !regexp.MustCompile(`^[0-9a-fA-F]{6}$`).MatchString(v) {
+		return "Invalid color, use hexadecimal notation."
+	}
+	return ""
+}
+
+func validateWireguardKey(_ *fakeOPNsense, v string) string {
+	raw, err := base64.StdEncoding.DecodeString(v)
+	if err != nil || len(raw) != 32 {
+		return "Invalid key."
+	}
+	return ""
+}
+
+func fakeKeyPair() (string, string) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(key.Bytes()), base64.StdEncoding.EncodeToString(key.PublicKey().Bytes())
+}
+
+func newFakeUUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func fakeString(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case bool:
+		if s {
+			return "1"
+		}
+		return "0"
+	}
+	return fmt.Sprint(v)
+}
+
+func copyItem(item map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(item))
+	for k, v := range item {
+		out[k] = v
+	}
+	return out
+}
+
+func writeFakeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
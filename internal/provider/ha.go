@@ -0,0 +1,141 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// haSyncEndpoint is the "Synchronize and reconfigure all" action of
+	// System > High Availability: it pushes the configuration to the backup
+	// node over XMLRPC and restarts the synchronized services there.
+	haSyncEndpoint = "core/hasync_status/restart_all"
+
+	defaultHASyncTimeout  = 120 * time.Second
+	haVerifyRetryInterval = 5 * time.Second
+)
+
+// haVerifyEndpoints lists, per apply endpoint, the model endpoints compared
+// between master and backup after a sync.
+var haVerifyEndpoints = map[string][]string{
+	filterApplyEndpoint:             {"firewall/filter/get"},
+	"firewall/alias/reconfigure":    {"firewall/alias/get"},
+	"firewall/apply":                {"firewall/d_nat/get"},
+	natSourceApplyEndpoint:          {"firewall/source_nat/get"},
+	"kea/service/reconfigure":       {"kea/dhcpv4/get"},
+	"wireguard/service/reconfigure": {"wireguard/server/get", "wireguard/client/get"},
+}
+
+// HAConfig describes the backup node of a CARP HA pair. The Client talks to
+// the master and triggers a config sync after every apply.
+type HAConfig struct {
+	// BackupHost, BackupApiKey and BackupApiSecret address the backup node;
+	// the credentials default to the master's. They are only needed for
+	// VerifyBackup.
+	BackupHost      string
+	BackupApiKey    string
+	BackupApiSecret string
+	// VerifyBackup reads the changed model back from the backup node and
+	// waits until it matches the master.
+	VerifyBackup bool
+	SyncTimeout  time.Duration
+}
+
+// haSync holds the HA settings of a Client.
+type haSync struct {
+	backup  *Client
+	verify  bool
+	timeout time.Duration
+}
+
+func newHASync(cfg ClientConfig) (*haSync, error) {
+	ha := cfg.HA
+	h := &haSync{verify: ha.VerifyBackup, timeout: ha.SyncTimeout}
+	if h.timeout <= 0 {
+		h.timeout = defaultHASyncTimeout
+	}
+	if !ha.VerifyBackup {
+		return h, nil
+	}
+	if ha.BackupHost == "" {
+		return nil, errors.New("verifying the HA backup requires its host")
+	}
+
+	backupCfg := cfg
+	backupCfg.Host = ha.BackupHost
+	backupCfg.HA = nil
+	backupCfg.cassetteNode = "backup"
+	if ha.BackupApiKey != "" {
+		backupCfg.ApiKey = ha.BackupApiKey
+	}
+	if ha.BackupApiSecret != "" {
+		backupCfg.ApiSecret = ha.BackupApiSecret
+	}
+	backup, err := NewClient(backupCfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create HA backup client: %w", err)
+	}
+	h.backup = backup
+	return h, nil
+}
+
+// syncHA pushes the configuration to the backup node after endpoint was
+// applied and, when enabled, waits until the backup reports the same model.
+func (c *Client) syncHA(ctx context.Context, endpoint string) error {
+	tflog.Debug(ctx, "Synchronizing HA backup", map[string]any{"endpoint": endpoint})
+
+	if err := c.postApply(ctx, haSyncEndpoint); err != nil {
+		return fmt.Errorf("unable to synchronize HA backup: %w", err)
+	}
+	if !c.ha.verify {
+		return nil
+	}
+
+	models := haVerifyEndpoints[endpoint]
+	if len(models) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.ha.timeout)
+	defer cancel()
+
+	for {
+		mismatch, err := c.compareBackup(ctx, models)
+		if err == nil && mismatch == "" {
+			return nil
+		}
+		if err == nil {
+			err = fmt.Errorf("%s differs between master and backup", mismatch)
+		}
+
+		tflog.Debug(ctx, "HA backup not in sync yet", map[string]any{"error": err.Error()})
+
+		if sleepContext(ctx, haVerifyRetryInterval) != nil {
+			return fmt.Errorf("HA backup did not match the master within %s "+
+				"(is this section enabled for synchronization?): %w", c.ha.timeout, err)
+		}
+	}
+}
+
+// compareBackup returns the first model endpoint whose content differs
+// between master and backup, or "" when all match.
+func (c *Client) compareBackup(ctx context.Context, models []string) (string, error) {
+	for _, model := range models {
+		var master, backup interface{}
+		if err := c.Get(ctx, model, &master); err != nil {
+			return "", err
+		}
+		if err := c.ha.backup.Get(ctx, model, &backup); err != nil {
+			return "", fmt.Errorf("backup: %w", err)
+		}
+		if !reflect.DeepEqual(master, backup) {
+			return model, nil
+		}
+	}
+	return "", nil
+}
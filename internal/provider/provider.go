@@ -2,10 +2,10 @@ package provider
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
-	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/path"
@@ -37,11 +37,44 @@ type opnsenseProvider struct {
 
 // opnsenseProviderModel maps provider schema data to a Go type.
 type opnsenseProviderModel struct {
-	Host          types.String `tfsdk:"host"`
-	ApiKey        types.String `tfsdk:"api_key"`
-	ApiSecret     types.String `tfsdk:"api_secret"`
-	Insecure      types.Bool   `tfsdk:"insecure"`
-	TimeoutSeconds types.Int64  `tfsdk:"timeout_seconds"`
+	Host           types.String     `tfsdk:"host"`
+	ApiKey         types.String     `tfsdk:"api_key"`
+	ApiSecret      types.String     `tfsdk:"api_secret"`
+	ApiKeyFile     types.String     `tfsdk:"api_key_file"`
+	ApiSecretFile  types.String     `tfsdk:"api_secret_file"`
+	CredsFile      types.String     `tfsdk:"credentials_file"`
+	CredsCommand   types.List       `tfsdk:"credentials_command"`
+	Insecure       types.Bool       `tfsdk:"insecure"`
+	CACertFile     types.String     `tfsdk:"ca_cert_file"`
+	CACertPEM      types.String     `tfsdk:"ca_cert_pem"`
+	ClientCertFile types.String     `tfsdk:"client_cert_file"`
+	ClientKeyFile  types.String     `tfsdk:"client_key_file"`
+	ClientCertPEM  types.String     `tfsdk:"client_cert_pem"`
+	ClientKeyPEM   types.String     `tfsdk:"client_key_pem"`
+	TLSServerName  types.String     `tfsdk:"tls_server_name"`
+	CertPin        types.String     `tfsdk:"cert_fingerprint"`
+	TimeoutSeconds types.Int64      `tfsdk:"timeout_seconds"`
+	SafeApply      types.Bool       `tfsdk:"safe_apply"`
+	ApplyMode      types.String     `tfsdk:"apply_mode"`
+	ApplyWarnings  types.Bool       `tfsdk:"apply_errors_as_warnings"`
+	MaxRetries     types.Int64      `tfsdk:"max_retries"`
+	RetryWaitMin   types.Int64      `tfsdk:"retry_wait_min_seconds"`
+	RetryWaitMax   types.Int64      `tfsdk:"retry_wait_max_seconds"`
+	RequestsPerSec types.Int64      `tfsdk:"max_requests_per_second"`
+	MaxWrites      types.Int64      `tfsdk:"max_concurrent_writes"`
+	WriteLockScope types.String     `tfsdk:"write_lock_scope"`
+	HA             *opnsenseHAModel `tfsdk:"ha"`
+	CassetteDir    types.String     `tfsdk:"cassette_dir"`
+	CassetteMode   types.String     `tfsdk:"cassette_mode"`
+}
+
+// opnsenseHAModel maps the ha block of the provider schema.
+type opnsenseHAModel struct {
+	BackupHost         types.String `tfsdk:"backup_host"`
+	BackupApiKey       types.String `tfsdk:"backup_api_key"`
+	BackupApiSecret    types.String `tfsdk:"backup_api_secret"`
+	VerifyBackup       types.Bool   `tfsdk:"verify_backup"`
+	SyncTimeoutSeconds types.Int64  `tfsdk:"sync_timeout_seconds"`
 }
 
 // Metadata returns the provider type name.
@@ -69,14 +102,151 @@ func (p *opnsenseProvider) Schema(_ context.Context, _ provider.SchemaRequest, r
 				Optional:    true,
 				Sensitive:   true,
 			},
+			"api_key_file": schema.StringAttribute{
+				Description: "Path to a file containing only the API key. Can also be set via OPNSENSE_API_KEY_FILE environment variable.",
+				Optional:    true,
+			},
+			"api_secret_file": schema.StringAttribute{
+				Description: "Path to a file containing only the API secret. Can also be set via OPNSENSE_API_SECRET_FILE environment variable.",
+				Optional:    true,
+			},
+			"credentials_file": schema.StringAttribute{
+				Description: "Path to the apikey.txt file OPNsense generates for a new API key (key=... and secret=... lines). " +
+					"Can also be set via OPNSENSE_CREDENTIALS_FILE environment variable.",
+				Optional: true,
+			},
+			"credentials_command": schema.ListAttribute{
+				Description: "Command and arguments run (without a shell) to obtain the credentials. It must print a JSON object with " +
+					"\"api_key\" and \"api_secret\" on stdout. Only run when api_key/api_secret, OPNSENSE_API_KEY/OPNSENSE_API_SECRET " +
+					"and the credential files do not provide both values.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
 			"insecure": schema.BoolAttribute{
 				Description: "Skip TLS certificate verification. Defaults to false.",
 				Optional:    true,
 			},
+			"ca_cert_file": schema.StringAttribute{
+				Description: "Path to a PEM bundle of CA certificates used to verify the OPNsense certificate. Can also be set via OPNSENSE_CA_CERT_FILE environment variable.",
+				Optional:    true,
+			},
+			"ca_cert_pem": schema.StringAttribute{
+				Description: "PEM bundle of CA certificates used to verify the OPNsense certificate. Takes precedence over ca_cert_file. Can also be set via OPNSENSE_CA_CERT_PEM environment variable.",
+				Optional:    true,
+			},
+			"client_cert_file": schema.StringAttribute{
+				Description: "Path to a PEM client certificate for mutual TLS. Requires a client key. Can also be set via OPNSENSE_CLIENT_CERT_FILE environment variable.",
+				Optional:    true,
+			},
+			"client_key_file": schema.StringAttribute{
+				Description: "Path to the PEM private key of the client certificate. Can also be set via OPNSENSE_CLIENT_KEY_FILE environment variable.",
+				Optional:    true,
+			},
+			"client_cert_pem": schema.StringAttribute{
+				Description: "PEM client certificate for mutual TLS. Takes precedence over client_cert_file. Can also be set via OPNSENSE_CLIENT_CERT_PEM environment variable.",
+				Optional:    true,
+			},
+			"client_key_pem": schema.StringAttribute{
+				Description: "PEM private key of the client certificate. Takes precedence over client_key_file. Can also be set via OPNSENSE_CLIENT_KEY_PEM environment variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"tls_server_name": schema.StringAttribute{
+				Description: "Server name used to verify the OPNsense certificate, when it differs from the host in the URL. Can also be set via OPNSENSE_TLS_SERVER_NAME environment variable.",
+				Optional:    true,
+			},
+			"cert_fingerprint": schema.StringAttribute{
+				Description: "SHA-256 fingerprint of the OPNsense server certificate (hex, colons optional). Connections to any other certificate are refused. " +
+					"Without a CA the pinned certificate is trusted on its own. Can also be set via OPNSENSE_CERT_FINGERPRINT environment variable.",
+				Optional: true,
+			},
 			"timeout_seconds": schema.Int64Attribute{
-				Description: "HTTP timeout in seconds. Defaults to 30.",
+				Description: "Timeout in seconds applied to each API request. Defaults to 30; 0 disables the timeout.",
+				Optional:    true,
+			},
+			"safe_apply": schema.BoolAttribute{
+				Description: "Apply firewall filter changes through an OPNsense savepoint. The new ruleset is only confirmed once the API is still reachable; " +
+					"otherwise the firewall rolls back on its own after its rollback timer expires. Defaults to false.",
+				Optional: true,
+			},
+			"apply_mode": schema.StringAttribute{
+				Description: "When to reconfigure services after a change: \"immediate\" runs apply/reconfigure after every create, update and delete; " +
+					"\"deferred\" debounces the reconfigures per service: one call runs once no change has arrived for 2 seconds, at most 30 seconds " +
+					"after the first. This is not a single flush at the end of the apply, so a long apply may reconfigure a service several " +
+					"times. Each resource waits for its batch and reports its result. Defaults to \"immediate\".",
+				Optional: true,
+			},
+			"apply_errors_as_warnings": schema.BoolAttribute{
+				Description: "Report failed apply/reconfigure calls as warnings instead of errors. Defaults to false.",
+				Optional:    true,
+			},
+			"max_retries": schema.Int64Attribute{
+				Description: "Number of times a request failing with a 5xx/429 status or a connection error is retried. " +
+					"Requests that create objects are only retried when they cannot have reached OPNsense. Defaults to 3; 0 disables retries.",
+				Optional: true,
+			},
+			"retry_wait_min_seconds": schema.Int64Attribute{
+				Description: "Initial wait before retrying a request; doubled for each further attempt, with jitter. Defaults to 1.",
+				Optional:    true,
+			},
+			"retry_wait_max_seconds": schema.Int64Attribute{
+				Description: "Maximum wait between retries. Defaults to 30.",
+				Optional:    true,
+			},
+			"max_requests_per_second": schema.Int64Attribute{
+				Description: "Maximum number of API requests sent per second. Defaults to 0 (unlimited).",
 				Optional:    true,
 			},
+			"max_concurrent_writes": schema.Int64Attribute{
+				Description: "Maximum number of requests that change the configuration in flight at once, per write_lock_scope. " +
+					"OPNsense does not guard config.xml against concurrent writers, so the default of 1 serializes them. Reads always run in parallel.",
+				Optional: true,
+			},
+			"write_lock_scope": schema.StringAttribute{
+				Description: "Scope of max_concurrent_writes: \"global\" limits writes across the whole host, " +
+					"\"subsystem\" limits them separately for firewall, Kea and WireGuard. Defaults to \"global\".",
+				Optional: true,
+			},
+			"cassette_dir": schema.StringAttribute{
+				Description: "Directory to record API requests and responses to, or to replay them from, instead of talking to OPNsense. " +
+					"API keys and secrets are replaced with REDACTED in the recording. Meant for capturing bugs as regression tests. " +
+					"Can also be set via OPNSENSE_CASSETTE_DIR environment variable.",
+				Optional: true,
+			},
+			"cassette_mode": schema.StringAttribute{
+				Description: "\"record\" to record real traffic into cassette_dir, \"replay\" to answer every request from it without network access. " +
+					"Defaults to \"replay\". Can also be set via OPNSENSE_CASSETTE_MODE environment variable.",
+				Optional: true,
+			},
+			"ha": schema.SingleNestedAttribute{
+				Description: "Treat host as the master of a CARP HA pair. After every apply the provider triggers the HA configuration sync " +
+					"(System > High Availability > Synchronize and reconfigure all) and waits for it.",
+				Optional: true,
+				Attributes: map[string]schema.Attribute{
+					"backup_host": schema.StringAttribute{
+						Description: "URL of the backup node. Required when verify_backup is true.",
+						Optional:    true,
+					},
+					"backup_api_key": schema.StringAttribute{
+						Description: "API key for the backup node. Defaults to the master's api_key.",
+						Optional:    true,
+						Sensitive:   true,
+					},
+					"backup_api_secret": schema.StringAttribute{
+						Description: "API secret for the backup node. Defaults to the master's api_secret.",
+						Optional:    true,
+						Sensitive:   true,
+					},
+					"verify_backup": schema.BoolAttribute{
+						Description: "After syncing, read the changed section back from the backup node and wait until it matches the master. Defaults to false.",
+						Optional:    true,
+					},
+					"sync_timeout_seconds": schema.Int64Attribute{
+						Description: "How long to wait for the backup node to match the master. Defaults to 120.",
+						Optional:    true,
+					},
+				},
+			},
 		},
 	}
 }
@@ -129,19 +299,36 @@ func (p *opnsenseProvider) Configure(ctx context.Context, req provider.Configure
 	// Default values to environment variables, but override
 	// with Terraform configuration value if set.
 	host := os.Getenv("OPNSENSE_HOST")
-	apiKey := os.Getenv("OPNSENSE_API_KEY")
-	apiSecret := os.Getenv("OPNSENSE_API_SECRET")
 
 	if !config.Host.IsNull() {
 		host = config.Host.ValueString()
 	}
 
-	if !config.ApiKey.IsNull() {
-		apiKey = config.ApiKey.ValueString()
+	// Credentials come from the configuration first, then from the
+	// environment, and finally from the credential files or command. The
+	// command only runs when neither provides both values.
+	apiKey := firstNonEmpty(config.ApiKey.ValueString(), os.Getenv("OPNSENSE_API_KEY"))
+	apiSecret := firstNonEmpty(config.ApiSecret.ValueString(), os.Getenv("OPNSENSE_API_SECRET"))
+
+	sources := CredentialSources{
+		ApiKeyFile:      stringWithEnv(config.ApiKeyFile, "OPNSENSE_API_KEY_FILE"),
+		ApiSecretFile:   stringWithEnv(config.ApiSecretFile, "OPNSENSE_API_SECRET_FILE"),
+		CredentialsFile: stringWithEnv(config.CredsFile, "OPNSENSE_CREDENTIALS_FILE"),
+	}
+	if !config.CredsCommand.IsNull() {
+		resp.Diagnostics.Append(config.CredsCommand.ElementsAs(ctx, &sources.Command, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 	}
 
-	if !config.ApiSecret.IsNull() {
-		apiSecret = config.ApiSecret.ValueString()
+	apiKey, apiSecret, err := sources.Resolve(ctx, apiKey, apiSecret)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Read OPNsense API Credentials",
+			"The provider could not read the OPNsense API credentials from the configured credential source: "+err.Error(),
+		)
+		return
 	}
 
 	// If any of the expected configurations are missing, return
@@ -161,8 +348,8 @@ func (p *opnsenseProvider) Configure(ctx context.Context, req provider.Configure
 			path.Root("api_key"),
 			"Missing OPNsense API Key",
 			"The provider cannot create the OPNsense API client as there is a missing or empty value for the OPNsense API key. "+
-				"Set the api_key value in the configuration or use the OPNSENSE_API_KEY environment variable. "+
-				"If either is already set, ensure the value is not empty.",
+				"Set the api_key, api_key_file, credentials_file or credentials_command value in the configuration "+
+				"or use the OPNSENSE_API_KEY environment variable. If either is already set, ensure the value is not empty.",
 		)
 	}
 
@@ -171,8 +358,8 @@ func (p *opnsenseProvider) Configure(ctx context.Context, req provider.Configure
 			path.Root("api_secret"),
 			"Missing OPNsense API Secret",
 			"The provider cannot create the OPNsense API client as there is a missing or empty value for the OPNsense API secret. "+
-				"Set the api_secret value in the configuration or use the OPNSENSE_API_SECRET environment variable. "+
-				"If either is already set, ensure the value is not empty.",
+				"Set the api_secret, api_secret_file, credentials_file or credentials_command value in the configuration "+
+				"or use the OPNSENSE_API_SECRET environment variable. If either is already set, ensure the value is not empty.",
 		)
 	}
 
@@ -180,10 +367,19 @@ func (p *opnsenseProvider) Configure(ctx context.Context, req provider.Configure
 		return
 	}
 
-	// Handle insecure TLS option
-	insecure := false
+	// Handle TLS options
+	tlsConfig := TLSConfig{
+		CACertFile:      stringWithEnv(config.CACertFile, "OPNSENSE_CA_CERT_FILE"),
+		CACertPEM:       stringWithEnv(config.CACertPEM, "OPNSENSE_CA_CERT_PEM"),
+		ClientCertFile:  stringWithEnv(config.ClientCertFile, "OPNSENSE_CLIENT_CERT_FILE"),
+		ClientKeyFile:   stringWithEnv(config.ClientKeyFile, "OPNSENSE_CLIENT_KEY_FILE"),
+		ClientCertPEM:   stringWithEnv(config.ClientCertPEM, "OPNSENSE_CLIENT_CERT_PEM"),
+		ClientKeyPEM:    stringWithEnv(config.ClientKeyPEM, "OPNSENSE_CLIENT_KEY_PEM"),
+		ServerName:      stringWithEnv(config.TLSServerName, "OPNSENSE_TLS_SERVER_NAME"),
+		CertFingerprint: stringWithEnv(config.CertPin, "OPNSENSE_CERT_FINGERPRINT"),
+	}
 	if !config.Insecure.IsNull() {
-		insecure = config.Insecure.ValueBool()
+		tlsConfig.Insecure = config.Insecure.ValueBool()
 	}
 
 	// Handle timeout
@@ -192,6 +388,144 @@ func (p *opnsenseProvider) Configure(ctx context.Context, req provider.Configure
 		timeout = config.TimeoutSeconds.ValueInt64()
 	}
 
+	if timeout < 0 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("timeout_seconds"),
+			"Invalid OPNsense API Timeout",
+			"The timeout_seconds value must be zero (no timeout) or a positive number of seconds.",
+		)
+		return
+	}
+
+	retry := map[string]types.Int64{
+		"max_retries":             config.MaxRetries,
+		"retry_wait_min_seconds":  config.RetryWaitMin,
+		"retry_wait_max_seconds":  config.RetryWaitMax,
+		"max_requests_per_second": config.RequestsPerSec,
+	}
+	for attr, value := range retry {
+		if !value.IsNull() && value.ValueInt64() < 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(attr),
+				"Invalid OPNsense Retry Setting",
+				fmt.Sprintf("The %s value must be zero or a positive number.", attr),
+			)
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	maxWrites := int64(defaultMaxConcurrentWrites)
+	if !config.MaxWrites.IsNull() {
+		maxWrites = config.MaxWrites.ValueInt64()
+	}
+
+	if maxWrites < 1 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("max_concurrent_writes"),
+			"Invalid OPNsense Write Concurrency",
+			"The max_concurrent_writes value must be at least 1.",
+		)
+		return
+	}
+
+	writeScope := writeScopeGlobal
+	if !config.WriteLockScope.IsNull() {
+		writeScope = config.WriteLockScope.ValueString()
+	}
+
+	if writeScope != writeScopeGlobal && writeScope != writeScopeSubsystem {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("write_lock_scope"),
+			"Invalid OPNsense Write Lock Scope",
+			fmt.Sprintf("The write_lock_scope value must be %q or %q, got %q.", writeScopeGlobal, writeScopeSubsystem, writeScope),
+		)
+		return
+	}
+
+	// NewClient treats zero as "use the default", so an explicit 0 here
+	// becomes a negative value meaning "no retries".
+	maxRetries := defaultMaxRetries
+	if !config.MaxRetries.IsNull() {
+		maxRetries = int(config.MaxRetries.ValueInt64())
+		if maxRetries == 0 {
+			maxRetries = -1
+		}
+	}
+
+	var ha *HAConfig
+	if config.HA != nil {
+		ha = &HAConfig{
+			BackupHost:      config.HA.BackupHost.ValueString(),
+			BackupApiKey:    config.HA.BackupApiKey.ValueString(),
+			BackupApiSecret: config.HA.BackupApiSecret.ValueString(),
+			VerifyBackup:    config.HA.VerifyBackup.ValueBool(),
+			SyncTimeout:     time.Duration(config.HA.SyncTimeoutSeconds.ValueInt64()) * time.Second,
+		}
+
+		if ha.VerifyBackup && ha.BackupHost == "" {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("ha").AtName("backup_host"),
+				"Missing OPNsense HA Backup Host",
+				"The ha.backup_host value is required when ha.verify_backup is true.",
+			)
+			return
+		}
+	}
+
+	safeApply := false
+	if !config.SafeApply.IsNull() {
+		safeApply = config.SafeApply.ValueBool()
+	}
+
+	applyMode := applyModeImmediate
+	if !config.ApplyMode.IsNull() {
+		applyMode = config.ApplyMode.ValueString()
+	}
+
+	if applyMode != applyModeImmediate && applyMode != applyModeDeferred {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("apply_mode"),
+			"Invalid OPNsense Apply Mode",
+			fmt.Sprintf("The apply_mode value must be %q or %q, got %q.", applyModeImmediate, applyModeDeferred, applyMode),
+		)
+		return
+	}
+
+	applyWarnings := false
+	if !config.ApplyWarnings.IsNull() {
+		applyWarnings = config.ApplyWarnings.ValueBool()
+	}
+
+	var cassette *Cassette
+	if dir := stringWithEnv(config.CassetteDir, "OPNSENSE_CASSETTE_DIR"); dir != "" {
+		mode := stringWithEnv(config.CassetteMode, "OPNSENSE_CASSETTE_MODE")
+		if mode == "" {
+			mode = cassetteModeReplay
+		}
+		if mode != cassetteModeRecord && mode != cassetteModeReplay {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("cassette_mode"),
+				"Invalid OPNsense Cassette Mode",
+				fmt.Sprintf("The cassette_mode value must be %q or %q, got %q.", cassetteModeRecord, cassetteModeReplay, mode),
+			)
+			return
+		}
+
+		var err error
+		cassette, err = OpenCassette(dir, mode)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("cassette_dir"),
+				"Unable to Open OPNsense Cassette",
+				err.Error(),
+			)
+			return
+		}
+		tflog.Info(ctx, "Using API cassette", map[string]any{"dir": dir, "mode": mode})
+	}
+
 	ctx = tflog.SetField(ctx, "opnsense_host", host)
 	ctx = tflog.SetField(ctx, "opnsense_api_key", apiKey)
 	ctx = tflog.SetField(ctx, "opnsense_api_secret", apiSecret)
@@ -201,7 +535,24 @@ func (p *opnsenseProvider) Configure(ctx context.Context, req provider.Configure
 	tflog.Debug(ctx, "Creating OPNsense client")
 
 	// Create a new OPNsense client using the configuration values
-	client, err := NewClient(&host, &apiKey, &apiSecret, insecure, timeout)
+	client, err := NewClient(ClientConfig{
+		Host:                  host,
+		ApiKey:                apiKey,
+		ApiSecret:             apiSecret,
+		TLS:                   tlsConfig,
+		Timeout:               time.Duration(timeout) * time.Second,
+		SafeApply:             safeApply,
+		ApplyMode:             applyMode,
+		ApplyErrorsAsWarnings: applyWarnings,
+		MaxRetries:            maxRetries,
+		RetryWaitMin:          time.Duration(config.RetryWaitMin.ValueInt64()) * time.Second,
+		RetryWaitMax:          time.Duration(config.RetryWaitMax.ValueInt64()) * time.Second,
+		RequestsPerSecond:     float64(config.RequestsPerSec.ValueInt64()),
+		MaxConcurrentWrites:   int(maxWrites),
+		WriteLockScope:        writeScope,
+		HA:                    ha,
+		Cassette:              cassette,
+	})
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to Create OPNsense API Client",
@@ -212,6 +563,24 @@ func (p *opnsenseProvider) Configure(ctx context.Context, req provider.Configure
 		return
 	}
 
+	// Look up the release and plugins once, so resources can reject
+	// configurations the firewall cannot serve at plan time.
+	caps, err := client.detectCapabilities(ctx)
+	if err != nil {
+		resp.Diagnostics.AddWarning(
+			"Unable to Detect OPNsense Version",
+			"The provider could not read the OPNsense version and installed plugins, so missing features are only reported when the API rejects a request. "+
+				"Grant the API user the \"System: Firmware\" privilege to enable these checks.\n\n"+
+				"OPNsense Client Error: "+err.Error(),
+		)
+	} else {
+		client.Capabilities = caps
+		tflog.Info(ctx, "Detected OPNsense version", map[string]any{
+			"version": caps.Version,
+			"plugins": strings.Join(caps.Plugins, ","),
+		})
+	}
+
 	// Make the OPNsense client available during DataSource and Resource
 	// type Configure methods.
 	resp.DataSourceData = client
@@ -224,6 +593,8 @@ func (p *opnsenseProvider) Configure(ctx context.Context, req provider.Configure
 func (p *opnsenseProvider) DataSources(_ context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewFirewallRuleDataSource,
+		NewSystemInfoDataSource,
+		NewWireguardPeerConfigDataSource,
 	}
 }
 
@@ -234,83 +605,20 @@ func (p *opnsenseProvider) Resources(_ context.Context) []func() resource.Resour
 		NewFirewallAliasResource,
 		NewFirewallCategoryResource,
 		NewNatDestinationResource,
+		NewNatSourceResource,
 		NewKeaReservationResource,
 		NewKeaSubnetResource,
 		NewWireguardServerResource,
 		NewWireguardPeerResource,
+		NewWireguardServerPeerAttachmentResource,
 	}
 }
 
-// Client represents the OPNsense API client
-type Client struct {
-	Host      string
-	ApiKey    string
-	ApiSecret string
-	client    *http.Client
-}
-
-// NewClient creates a new OPNsense API client
-func NewClient(host, apiKey, apiSecret *string, insecure bool, timeout int64) (*Client, error) {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
-	}
-
-	httpClient := &http.Client{
-		Transport: tr,
-		Timeout:   0, // We'll handle timeouts per-request
-	}
-
-	c := &Client{
-		Host:      *host,
-		ApiKey:    *apiKey,
-		ApiSecret: *apiSecret,
-		client:    httpClient,
-	}
-
-	return c, nil
-}
-
-// DoRequest performs an HTTP request to the OPNsense API
-func (c *Client) DoRequest(ctx context.Context, method, endpoint string, body []byte) ([]byte, error) {
-	url := fmt.Sprintf("%s/api/%s", c.Host, endpoint)
-	
-	tflog.Debug(ctx, "Making API request", map[string]any{
-		"method":   method,
-		"endpoint": endpoint,
-		"url":      url,
-	})
-
-	req, err := http.NewRequestWithContext(ctx, method, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
-	}
-
-	// Set basic auth
-	req.SetBasicAuth(c.ApiKey, c.ApiSecret)
-
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
-	if body != nil && len(body) > 0 {
-		req.Body = http.NoBody
-		// For POST requests with body, we'd need to set the body properly
-		// This is simplified for the example
-	}
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error making request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
+// stringWithEnv returns the configured value of an optional attribute, or
+// the environment variable env when the attribute is not set.
+func stringWithEnv(value types.String, env string) string {
+	if !value.IsNull() && !value.IsUnknown() {
+		return value.ValueString()
 	}
-
-	// Read response body
-	var respBody []byte
-	// In a real implementation, you'd read the response body here
-	
-	return respBody, nil
+	return os.Getenv(env)
 }
@@ -0,0 +1,263 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// testAccProtoV6ProviderFactories serves the provider in-process to the
+// Terraform CLI used by the tests.
+var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
+	"opnsense": providerserver.NewProtocol6WithError(New("test")()),
+}
+
+// testAccPreCheck skips tests that drive the Terraform CLI when none is
+// installed. They talk to a fake OPNsense and need no network access, so
+// they run without TF_ACC; point TF_ACC_TERRAFORM_PATH at a binary to pick
+// a specific one.
+func testAccPreCheck(t *testing.T) {
+	t.Helper()
+	if os.Getenv("TF_ACC_TERRAFORM_PATH") != "" {
+		return
+	}
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("terraform CLI not found in PATH; set TF_ACC_TERRAFORM_PATH to run this test")
+	}
+}
+
+// fakeTest runs a resource.Test against f without requiring TF_ACC.
+func fakeTest(t *testing.T, f *fakeOPNsense, steps ...resource.TestStep) {
+	t.Helper()
+	fakeTestCase(t, f, resource.TestCase{Steps: steps})
+}
+
+// fakeTestCase is fakeTest for test cases that need more than steps, e.g.
+// a minimum Terraform version.
+func fakeTestCase(t *testing.T, f *fakeOPNsense, tc resource.TestCase) {
+	t.Helper()
+	testAccPreCheck(t)
+	for i := range tc.Steps {
+		if tc.Steps[i].Config != "" {
+			tc.Steps[i].Config = f.ProviderConfig() + tc.Steps[i].Config
+		}
+	}
+	tc.IsUnitTest = true
+	tc.ProtoV6ProviderFactories = testAccProtoV6ProviderFactories
+	resource.Test(t, tc)
+}
+
+// captureID stores the id of a resource in the state into id, for later
+// steps that change the fake behind Terraform's back.
+func captureID(name string, id *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("%s not found in state", name)
+		}
+		*id = rs.Primary.ID
+		return nil
+	}
+}
+
+// checkFakeItem runs check against the fake's copy of the item behind a
+// resource.
+func checkFakeItem(f *fakeOPNsense, model, name string, check func(item map[string]interface{}) error) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("%s not found in state", name)
+		}
+		item := f.Item(model, rs.Primary.ID)
+		if item == nil {
+			return fmt.Errorf("%s %s not found in OPNsense", model, rs.Primary.ID)
+		}
+		return check(item)
+	}
+}
+
+// checkFakeField asserts a stored field value.
+func checkFakeField(f *fakeOPNsense, model, name, field, want string) resource.TestCheckFunc {
+	return checkFakeItem(f, model, name, func(item map[string]interface{}) error {
+		if got := fakeString(item[field]); got != want {
+			return fmt.Errorf("%s.%s = %q, want %q", model, field, got, want)
+		}
+		return nil
+	})
+}
+
+// checkFakeEmpty asserts that every item of model has been deleted.
+func checkFakeEmpty(f *fakeOPNsense, model string) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		if items := f.Items(model); len(items) > 0 {
+			return fmt.Errorf("%d %s item(s) left after destroy", len(items), model)
+		}
+		return nil
+	}
+}
+
+// checkApplied asserts that endpoint was applied at least once.
+func checkApplied(f *fakeOPNsense, endpoint string) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		if f.Applies(endpoint) == 0 {
+			return fmt.Errorf("%s was never called", endpoint)
+		}
+		return nil
+	}
+}
+
+func TestProviderRejectsMissingPlugin(t *testing.T) {
+	f := newFakeOPNsense(t)
+	f.SetVersion("23.7.12")
+
+	fakeTest(t, f, resource.TestStep{
+		Config: `
+resource "opnsense_wireguard_peer" "test" {
+  name        = "laptop"
+  public_key  = "dGVzdC1wdWJsaWMta2V5LTMyLWJ5dGVzLWxvbmchISE="
+  allowed_ips = "10.20.30.2/32"
+}
+`,
+		ExpectError: regexp.MustCompile(`install\s+the\s+os-wireguard\s+plugin`),
+	})
+}
+
+func TestProviderAuthenticationFailure(t *testing.T) {
+	f := newFakeOPNsense(t)
+	f.APISecret = "other-secret"
+
+	fakeTest(t, f, resource.TestStep{
+		Config: `
+resource "opnsense_firewall_category" "test" {
+  name = "web"
+}
+`,
+		ExpectError: regexp.MustCompile(`401`),
+	})
+}
+
+func TestProviderEnvCredentialsBeforeCommand(t *testing.T) {
+	f := newFakeOPNsense(t)
+	f.APIKey, f.APISecret = "", ""
+	// The command would fail; it must not run when the environment has
+	// both values.
+	f.ProviderSettings = `credentials_command = ["` + filepath.Join(t.TempDir(), "missing") + `"]`
+	t.Setenv("OPNSENSE_API_KEY", fakeAPIKey)
+	t.Setenv("OPNSENSE_API_SECRET", fakeAPISecret)
+
+	fakeTest(t, f, resource.TestStep{
+		Config: `
+resource "opnsense_firewall_category" "test" {
+  name = "web"
+}
+`,
+		Check: checkFakeField(f, "category", "opnsense_firewall_category.test", "name", "web"),
+	})
+}
+
+func TestClientDeferredApply(t *testing.T) {
+	f := newFakeOPNsense(t)
+	client, err := NewClient(ClientConfig{Host: f.Server.URL, ApiKey: f.APIKey, ApiSecret: f.APISecret, ApplyMode: applyModeDeferred})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const endpoint = "kea/service/reconfigure"
+
+	// Changes made together share one reconfigure.
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = client.Reconfigure(context.Background(), endpoint)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("Reconfigure = %v", err)
+		}
+	}
+	if got := f.Applies(endpoint); got != 1 {
+		t.Errorf("%s called %d times, want 1", endpoint, got)
+	}
+
+	// A cancelled caller flushes right away and still gets the result.
+	f.FailNext(endpoint, http.StatusBadRequest)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = client.Reconfigure(ctx, endpoint)
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("cancelled Reconfigure = %v, want the failed reconfigure", err)
+	}
+	if elapsed := time.Since(start); elapsed >= applyBatchWindow {
+		t.Errorf("cancelled Reconfigure took %s, want it flushed before the quiet window", elapsed)
+	}
+}
+
+func TestClientAgainstFake(t *testing.T) {
+	f := newFakeOPNsense(t)
+	client, err := NewClient(ClientConfig{Host: f.Server.URL, ApiKey: f.APIKey, ApiSecret: f.APISecret, RetryWaitMin: 1, RetryWaitMax: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var created map[string]interface{}
+	err = client.Post(ctx, "firewall/category/addItem", map[string]interface{}{
+		"category": map[string]interface{}{"name": "web", "color": "ff0000"},
+	}, &created)
+	if err != nil {
+		t.Fatalf("addItem: %v", err)
+	}
+	uuid := stringValue(created["uuid"])
+
+	item, err := client.GetItem(ctx, "firewall/category/getItem/"+uuid, "category")
+	if err != nil || stringValue(item["name"]) != "web" {
+		t.Fatalf("getItem = %v, %v; want the category", item, err)
+	}
+	item, err = client.GetItem(ctx, "firewall/category/getItem/00000000-0000-4000-8000-000000000000", "category")
+	if err != nil || item != nil {
+		t.Fatalf("getItem of unknown UUID = %v, %v; want nil, nil", item, err)
+	}
+
+	err = client.Post(ctx, "firewall/category/addItem", map[string]interface{}{
+		"category": map[string]interface{}{"name": "web", "color": "red"},
+	}, nil)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("duplicate addItem error = %v, want a ValidationError", err)
+	}
+	if got := verr.fields(); len(got) != 2 {
+		t.Errorf("validation fields = %v, want name and color", got)
+	}
+
+	f.FailNext("firewall/category/getItem", 503)
+	if _, err := client.GetItem(ctx, "firewall/category/getItem/"+uuid, "category"); err != nil {
+		t.Errorf("getItem after a 503 = %v, want it retried", err)
+	}
+
+	f.FailNext("firewall/category/addItem", 503)
+	err = client.Post(ctx, "firewall/category/addItem", map[string]interface{}{
+		"category": map[string]interface{}{"name": "db"},
+	}, nil)
+	if err == nil {
+		t.Error("addItem after a 503 succeeded, want it not retried")
+	}
+}
@@ -2,10 +2,7 @@ package provider
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
@@ -37,6 +34,15 @@ type FirewallAliasResourceModel struct {
 	Enabled     types.Bool   `tfsdk:"enabled"`
 }
 
+// firewallAliasFields maps OPNsense alias fields to resource attributes.
+var firewallAliasFields = map[string]string{
+	"name":        "name",
+	"type":        "type",
+	"content":     "content",
+	"description": "description",
+	"enabled":     "enabled",
+}
+
 func (r *FirewallAliasResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_firewall_alias"
 }
@@ -62,7 +68,7 @@ func (r *FirewallAliasResource) Schema(ctx context.Context, req resource.SchemaR
 				Required:            true,
 			},
 			"content": schema.ListAttribute{
-				MarkdownDescription: "List of alias entries (IPs, networks, ports, etc.)",
+				MarkdownDescription: "List of alias entries (IPs, networks, ports, etc.). OPNsense may return entries in a different order; that is not reported as drift.",
 				Required:            true,
 				ElementType:         types.StringType,
 			},
@@ -106,7 +112,7 @@ func (r *FirewallAliasResource) Create(ctx context.Context, req resource.CreateR
 	// Convert content list to newline-separated string (not comma-separated!)
 	var contentItems []string
 	resp.Diagnostics.Append(data.Content.ElementsAs(ctx, &contentItems, false)...)
-	contentStr := strings.Join(contentItems, "\n")  // Changed from "," to "\n"
+	contentStr := strings.Join(contentItems, "\n") // Changed from "," to "\n"
 
 	aliasData := map[string]interface{}{
 		"alias": map[string]interface{}{
@@ -129,49 +135,27 @@ func (r *FirewallAliasResource) Create(ctx context.Context, req resource.CreateR
 		aliasData["alias"].(map[string]interface{})["enabled"] = "1"
 	}
 
-	jsonData, _ := json.Marshal(aliasData)
-
-	url := fmt.Sprintf("%s/api/firewall/alias/addItem", r.client.Host)
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create alias: %s", err))
-		return
-	}
-	defer httpResp.Body.Close()
-
-	body, _ := io.ReadAll(httpResp.Body)
-
-	// Debug: Log raw response
-	tflog.Debug(ctx, "Raw API Response", map[string]any{
-		"status_code": httpResp.StatusCode,
-		"body":        string(body),
-	})
-
 	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to parse response: %s. Body: %s", err, string(body)))
+	if err := r.client.Post(ctx, "firewall/alias/addItem", aliasData, &result); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to create alias", err, firewallAliasFields)
 		return
 	}
 
 	// Try multiple possible response formats
 	var uuid string
-	
+
 	// Format 1: {"uuid": "..."}
 	if val, ok := result["uuid"].(string); ok && val != "" {
 		uuid = val
 	}
-	
+
 	// Format 2: {"result": "saved", "uuid": "..."}
 	if uuid == "" {
 		if val, ok := result["uuid"].(string); ok && val != "" {
 			uuid = val
 		}
 	}
-	
+
 	// Format 3: Check if there's a nested structure
 	if uuid == "" {
 		if alias, ok := result["alias"].(map[string]interface{}); ok {
@@ -186,14 +170,14 @@ func (r *FirewallAliasResource) Create(ctx context.Context, req resource.CreateR
 		// Log what we got to help debug
 		tflog.Warn(ctx, "UUID not found in expected locations", map[string]any{
 			"response_keys": fmt.Sprintf("%v", getKeys(result)),
-			"full_response": string(body),
+			"full_response": fmt.Sprintf("%v", result),
 		})
 	}
 
 	if uuid == "" {
 		resp.Diagnostics.AddError(
-			"API Error", 
-			fmt.Sprintf("No UUID returned from API. Response: %s", string(body)),
+			"API Error",
+			fmt.Sprintf("No UUID returned from API. Response: %v", result),
 		)
 		return
 	}
@@ -201,10 +185,7 @@ func (r *FirewallAliasResource) Create(ctx context.Context, req resource.CreateR
 	data.ID = types.StringValue(uuid)
 
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/firewall/alias/reconfigure", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "firewall/alias/reconfigure", &resp.Diagnostics)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -216,22 +197,28 @@ func (r *FirewallAliasResource) Read(ctx context.Context, req resource.ReadReque
 		return
 	}
 
-	url := fmt.Sprintf("%s/api/firewall/alias/getItem/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
+	alias, err := r.client.GetItem(ctx, "firewall/alias/getItem/"+data.ID.ValueString(), "alias")
 	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read alias: %s", err))
+		addClientError(&resp.Diagnostics, "Unable to read alias", err, firewallAliasFields)
 		return
 	}
-	defer httpResp.Body.Close()
-
-	if httpResp.StatusCode == 404 {
+	if alias == nil {
 		resp.State.RemoveResource(ctx)
 		return
 	}
 
+	data.Name = types.StringValue(stringValue(alias["name"]))
+	if aliasType := stringValue(alias["type"]); !strings.EqualFold(data.Type.ValueString(), aliasType) {
+		data.Type = types.StringValue(aliasType)
+	}
+	// OPNsense does not preserve entry order, so content is compared as a set
+	data.Content = refreshStringList(ctx, data.Content, parseAliasContent(alias["content"]), &resp.Diagnostics)
+	data.Description = refreshString(data.Description, stringValue(alias["description"]), "")
+	data.Enabled = refreshBool(data.Enabled, boolValue(alias["enabled"]), true)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
@@ -244,46 +231,27 @@ func (r *FirewallAliasResource) Update(ctx context.Context, req resource.UpdateR
 
 	var contentItems []string
 	resp.Diagnostics.Append(data.Content.ElementsAs(ctx, &contentItems, false)...)
-	contentStr := strings.Join(contentItems, "\n")  // Changed from "," to "\n"
+	contentStr := strings.Join(contentItems, "\n") // Changed from "," to "\n"
 
+	// description and enabled are always sent, so values removed from the
+	// configuration go back to the defaults Read compares against.
 	aliasData := map[string]interface{}{
 		"alias": map[string]interface{}{
-			"name":    data.Name.ValueString(),
-			"type":    data.Type.ValueString(),
-			"content": contentStr,
+			"name":        data.Name.ValueString(),
+			"type":        data.Type.ValueString(),
+			"content":     contentStr,
+			"description": data.Description.ValueString(),
+			"enabled":     boolFlag(data.Enabled, true),
 		},
 	}
 
-	if !data.Description.IsNull() {
-		aliasData["alias"].(map[string]interface{})["description"] = data.Description.ValueString()
-	}
-	if !data.Enabled.IsNull() {
-		if data.Enabled.ValueBool() {
-			aliasData["alias"].(map[string]interface{})["enabled"] = "1"
-		} else {
-			aliasData["alias"].(map[string]interface{})["enabled"] = "0"
-		}
-	}
-
-	jsonData, _ := json.Marshal(aliasData)
-
-	url := fmt.Sprintf("%s/api/firewall/alias/setItem/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update alias: %s", err))
+	if err := r.client.Post(ctx, "firewall/alias/setItem/"+data.ID.ValueString(), aliasData, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to update alias", err, firewallAliasFields)
 		return
 	}
-	defer httpResp.Body.Close()
 
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/firewall/alias/reconfigure", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "firewall/alias/reconfigure", &resp.Diagnostics)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -295,28 +263,36 @@ func (r *FirewallAliasResource) Delete(ctx context.Context, req resource.DeleteR
 		return
 	}
 
-	url := fmt.Sprintf("%s/api/firewall/alias/delItem/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, nil)
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete alias: %s", err))
+	if err := r.client.Post(ctx, "firewall/alias/delItem/"+data.ID.ValueString(), nil, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to delete alias", err, firewallAliasFields)
 		return
 	}
-	defer httpResp.Body.Close()
 
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/firewall/alias/reconfigure", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "firewall/alias/reconfigure", &resp.Diagnostics)
 }
 
 func (r *FirewallAliasResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
 
+// parseAliasContent decodes alias content, which OPNsense returns either as
+// the newline-separated string it stores or as a map of selected entries.
+func parseAliasContent(v interface{}) []string {
+	content, ok := v.(string)
+	if !ok {
+		return selectedOptions(v)
+	}
+
+	var items []string
+	for _, item := range strings.Split(content, "\n") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // Helper function to get keys from map for debugging
 func getKeys(m map[string]interface{}) []string {
 	keys := make([]string, 0, len(m))
@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestFirewallAliasResource(t *testing.T) {
+	f := newFakeOPNsense(t)
+	var id string
+
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: `
+resource "opnsense_firewall_alias" "test" {
+  name        = "web_servers"
+  type        = "host"
+  content     = ["10.0.0.10", "10.0.0.11"]
+  description = "Web servers"
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttrSet("opnsense_firewall_alias.test", "id"),
+				captureID("opnsense_firewall_alias.test", &id),
+				checkFakeField(f, "alias", "opnsense_firewall_alias.test", "content", "10.0.0.10\n10.0.0.11"),
+				checkApplied(f, "firewall/alias/reconfigure"),
+			),
+		},
+		resource.TestStep{
+			Config: `
+resource "opnsense_firewall_alias" "test" {
+  name        = "web_servers"
+  type        = "host"
+  content     = ["10.0.0.11", "10.0.0.12"]
+  description = "Web servers"
+  enabled     = false
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("opnsense_firewall_alias.test", "content.#", "2"),
+				checkFakeField(f, "alias", "opnsense_firewall_alias.test", "content", "10.0.0.11\n10.0.0.12"),
+				checkFakeField(f, "alias", "opnsense_firewall_alias.test", "enabled", "0"),
+			),
+		},
+		resource.TestStep{
+			ResourceName:      "opnsense_firewall_alias.test",
+			ImportState:       true,
+			ImportStateVerify: true,
+		},
+		resource.TestStep{
+			// A change made in the GUI shows up as drift.
+			PreConfig: func() {
+				f.Update("alias", id, map[string]interface{}{"content": "10.0.0.99"})
+			},
+			Config: `
+resource "opnsense_firewall_alias" "test" {
+  name        = "web_servers"
+  type        = "host"
+  content     = ["10.0.0.11", "10.0.0.12"]
+  description = "Web servers"
+  enabled     = false
+}
+`,
+			PlanOnly:           true,
+			ExpectNonEmptyPlan: true,
+		},
+	)
+
+	if items := f.Items("alias"); len(items) != 0 {
+		t.Errorf("%d alias(es) left after destroy", len(items))
+	}
+}
+
+func TestFirewallAliasResourceUnsetAttributes(t *testing.T) {
+	f := newFakeOPNsense(t)
+	unset := `
+resource "opnsense_firewall_alias" "test" {
+  name    = "web_servers"
+  type    = "host"
+  content = ["10.0.0.10"]
+}
+`
+
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: `
+resource "opnsense_firewall_alias" "test" {
+  name        = "web_servers"
+  type        = "host"
+  content     = ["10.0.0.10"]
+  description = "Web servers"
+  enabled     = false
+}
+`,
+		},
+		resource.TestStep{
+			Config: unset,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkFakeField(f, "alias", "opnsense_firewall_alias.test", "description", ""),
+				checkFakeField(f, "alias", "opnsense_firewall_alias.test", "enabled", "1"),
+			),
+		},
+		resource.TestStep{
+			Config:   unset,
+			PlanOnly: true,
+		},
+	)
+}
+
+func TestFirewallAliasResourceValidation(t *testing.T) {
+	f := newFakeOPNsense(t)
+
+	fakeTest(t, f, resource.TestStep{
+		Config: `
+resource "opnsense_firewall_alias" "test" {
+  name    = "web servers"
+  type    = "host"
+  content = ["10.0.0.10"]
+}
+`,
+		ExpectError: regexp.MustCompile(`may\s+only\s+consist\s+of\s+letters`),
+	})
+}
@@ -2,11 +2,7 @@ package provider
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -34,6 +30,13 @@ type FirewallCategoryResourceModel struct {
 	Auto  types.Bool   `tfsdk:"auto"`
 }
 
+// firewallCategoryFields maps OPNsense category fields to resource attributes.
+var firewallCategoryFields = map[string]string{
+	"name":  "name",
+	"color": "color",
+	"auto":  "auto",
+}
+
 func (r *FirewallCategoryResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_firewall_category"
 }
@@ -108,25 +111,9 @@ func (r *FirewallCategoryResource) Create(ctx context.Context, req resource.Crea
 		}
 	}
 
-	jsonData, _ := json.Marshal(categoryData)
-
-	url := fmt.Sprintf("%s/api/firewall/category/addItem", r.client.Host)
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create category: %s", err))
-		return
-	}
-	defer httpResp.Body.Close()
-
-	body, _ := io.ReadAll(httpResp.Body)
-
 	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to parse response: %s", err))
+	if err := r.client.Post(ctx, "firewall/category/addItem", categoryData, &result); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to create category", err, firewallCategoryFields)
 		return
 	}
 
@@ -147,22 +134,20 @@ func (r *FirewallCategoryResource) Read(ctx context.Context, req resource.ReadRe
 		return
 	}
 
-	url := fmt.Sprintf("%s/api/firewall/category/getItem/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
+	category, err := r.client.GetItem(ctx, "firewall/category/getItem/"+data.ID.ValueString(), "category")
 	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read category: %s", err))
+		addClientError(&resp.Diagnostics, "Unable to read category", err, firewallCategoryFields)
 		return
 	}
-	defer httpResp.Body.Close()
-
-	if httpResp.StatusCode == 404 {
+	if category == nil {
 		resp.State.RemoveResource(ctx)
 		return
 	}
 
+	data.Name = types.StringValue(stringValue(category["name"]))
+	data.Color = refreshString(data.Color, stringValue(category["color"]), "")
+	data.Auto = refreshBool(data.Auto, boolValue(category["auto"]), false)
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
@@ -191,19 +176,10 @@ func (r *FirewallCategoryResource) Update(ctx context.Context, req resource.Upda
 		}
 	}
 
-	jsonData, _ := json.Marshal(categoryData)
-
-	url := fmt.Sprintf("%s/api/firewall/category/setItem/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update category: %s", err))
+	if err := r.client.Post(ctx, "firewall/category/setItem/"+data.ID.ValueString(), categoryData, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to update category", err, firewallCategoryFields)
 		return
 	}
-	defer httpResp.Body.Close()
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -215,16 +191,10 @@ func (r *FirewallCategoryResource) Delete(ctx context.Context, req resource.Dele
 		return
 	}
 
-	url := fmt.Sprintf("%s/api/firewall/category/delItem/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, nil)
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete category: %s", err))
+	if err := r.client.Post(ctx, "firewall/category/delItem/"+data.ID.ValueString(), nil, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to delete category", err, firewallCategoryFields)
 		return
 	}
-	defer httpResp.Body.Close()
 }
 
 func (r *FirewallCategoryResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
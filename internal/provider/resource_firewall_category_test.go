@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestFirewallCategoryResource(t *testing.T) {
+	f := newFakeOPNsense(t)
+	var id string
+
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: `
+resource "opnsense_firewall_category" "test" {
+  name  = "web"
+  color = "ff0000"
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				captureID("opnsense_firewall_category.test", &id),
+				checkFakeField(f, "category", "opnsense_firewall_category.test", "name", "web"),
+				checkFakeField(f, "category", "opnsense_firewall_category.test", "color", "ff0000"),
+			),
+		},
+		resource.TestStep{
+			Config: `
+resource "opnsense_firewall_category" "test" {
+  name  = "web"
+  color = "00ff00"
+  auto  = true
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkFakeField(f, "category", "opnsense_firewall_category.test", "color", "00ff00"),
+				checkFakeField(f, "category", "opnsense_firewall_category.test", "auto", "1"),
+			),
+		},
+		resource.TestStep{
+			ResourceName:      "opnsense_firewall_category.test",
+			ImportState:       true,
+			ImportStateVerify: true,
+		},
+		resource.TestStep{
+			// A category deleted in the GUI is planned for creation again.
+			PreConfig: func() { f.Remove("category", id) },
+			Config: `
+resource "opnsense_firewall_category" "test" {
+  name  = "web"
+  color = "00ff00"
+  auto  = true
+}
+`,
+			PlanOnly:           true,
+			ExpectNonEmptyPlan: true,
+		},
+	)
+}
@@ -2,12 +2,10 @@ package provider
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -20,6 +18,7 @@ import (
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &FirewallRuleResource{}
 var _ resource.ResourceWithImportState = &FirewallRuleResource{}
+var _ resource.ResourceWithModifyPlan = &FirewallRuleResource{}
 
 func NewFirewallRuleResource() resource.Resource {
 	return &FirewallRuleResource{}
@@ -54,6 +53,28 @@ type FirewallRuleResourceModel struct {
 	Categories     types.List `tfsdk:"categories"`
 }
 
+// firewallRuleFields maps OPNsense rule fields to resource attributes.
+var firewallRuleFields = map[string]string{
+	"description":      "description",
+	"sequence":         "sequence",
+	"interface":        "interface",
+	"direction":        "direction",
+	"ipprotocol":       "ip_protocol",
+	"protocol":         "protocol",
+	"source_net":       "source_net",
+	"source_port":      "source_port",
+	"source_not":       "source_not",
+	"destination_net":  "destination_net",
+	"destination_port": "destination_port",
+	"destination_not":  "invert",
+	"action":           "action",
+	"enabled":          "enabled",
+	"log":              "log",
+	"quick":            "quick",
+	"category":         "categories",
+	"categories":       "categories",
+}
+
 func (r *FirewallRuleResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_firewall_rule"
 }
@@ -169,6 +190,14 @@ func (r *FirewallRuleResource) Configure(ctx context.Context, req resource.Confi
 	r.client = client
 }
 
+// ModifyPlan fails the plan when the firewall does not provide firewall filter rules.
+func (r *FirewallRuleResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	r.client.requireSubsystem(subsystemFilter, &resp.Diagnostics)
+}
+
 func (r *FirewallRuleResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var data FirewallRuleResourceModel
 
@@ -181,15 +210,15 @@ func (r *FirewallRuleResource) Create(ctx context.Context, req resource.CreateRe
 	// Prepare rule data
 	ruleData := map[string]interface{}{
 		"rule": map[string]interface{}{
-			"description": data.Description.ValueString(),
-			"source_net":  data.SourceNet.ValueString(),
+			"description":     data.Description.ValueString(),
+			"source_net":      data.SourceNet.ValueString(),
 			"destination_net": data.DestNet.ValueString(),
-			"protocol":    data.Protocol.ValueString(),
+			"protocol":        data.Protocol.ValueString(),
 		},
 	}
 
 	// Add sequence if provided
-	if !data.Sequence.IsNull() {
+	if !data.Sequence.IsNull() && !data.Sequence.IsUnknown() {
 		ruleData["rule"].(map[string]interface{})["sequence"] = fmt.Sprintf("%d", data.Sequence.ValueInt64())
 	}
 
@@ -266,19 +295,19 @@ func (r *FirewallRuleResource) Create(ctx context.Context, req resource.CreateRe
 	}
 	if !data.Categories.IsNull() && !data.Categories.IsUnknown() {
 		tflog.Debug(ctx, "Categories field is present", map[string]any{
-			"isNull": data.Categories.IsNull(),
+			"isNull":    data.Categories.IsNull(),
 			"isUnknown": data.Categories.IsUnknown(),
 		})
 		var categories []string
 		diags := data.Categories.ElementsAs(ctx, &categories, false)
 		resp.Diagnostics.Append(diags...)
-		
+
 		tflog.Debug(ctx, "After parsing categories", map[string]any{
-			"count": len(categories),
-			"hasError": diags.HasError(),
+			"count":      len(categories),
+			"hasError":   diags.HasError(),
 			"categories": fmt.Sprintf("%v", categories),
 		})
-		
+
 		if !diags.HasError() && len(categories) > 0 {
 			// Filter out empty/invalid UUIDs
 			validCategories := make([]string, 0, len(categories))
@@ -290,13 +319,13 @@ func (r *FirewallRuleResource) Create(ctx context.Context, req resource.CreateRe
 					tflog.Warn(ctx, "Skipping empty category UUID")
 				}
 			}
-			
+
 			if len(validCategories) > 0 {
 				categoryStr := strings.Join(validCategories, ",")
 				ruleData["rule"].(map[string]interface{})["category"] = categoryStr
 				tflog.Info(ctx, "Setting categories on rule", map[string]any{
 					"categories": categoryStr,
-					"count": len(validCategories),
+					"count":      len(validCategories),
 				})
 			} else {
 				tflog.Warn(ctx, "No valid category UUIDs found")
@@ -310,59 +339,18 @@ func (r *FirewallRuleResource) Create(ctx context.Context, req resource.CreateRe
 		}
 	} else {
 		tflog.Debug(ctx, "No categories field", map[string]any{
-			"isNull": data.Categories.IsNull(),
+			"isNull":    data.Categories.IsNull(),
 			"isUnknown": data.Categories.IsUnknown(),
 		})
 	}
 
 	// Make API call to create rule
-	jsonData, err := json.Marshal(ruleData)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to marshal rule data: %s", err))
-		return
-	}
-
-	url := fmt.Sprintf("%s/api/firewall/filter/addRule", r.client.Host)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create request: %s", err))
-		return
-	}
-
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	tflog.Debug(ctx, "Creating firewall rule", map[string]any{"url": url})
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create rule: %s", err))
-		return
-	}
-	defer httpResp.Body.Close()
-
-	body, err := io.ReadAll(httpResp.Body)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read response: %s", err))
-		return
-	}
-
-	if httpResp.StatusCode != 200 {
-		resp.Diagnostics.AddError("API Error", fmt.Sprintf("API returned status %d: %s", httpResp.StatusCode, string(body)))
-		return
-	}
-
 	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to parse response: %s\nRaw response: %s", err, string(body)))
+	if err := r.client.Post(ctx, "firewall/filter/addRule", ruleData, &result); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to create rule", err, firewallRuleFields)
 		return
 	}
 
-	// Log the full response for debugging
-	if resultJSON, err := json.MarshalIndent(result, "", "  "); err == nil {
-		resp.Diagnostics.AddWarning("API Response", fmt.Sprintf("Full response: %s", string(resultJSON)))
-	}
-
 	// Try to extract UUID from various possible response formats
 	var uuid string
 	if uuidVal, ok := result["uuid"].(string); ok {
@@ -380,15 +368,14 @@ func (r *FirewallRuleResource) Create(ctx context.Context, req resource.CreateRe
 	if uuid != "" {
 		data.ID = types.StringValue(uuid)
 	} else {
-		resp.Diagnostics.AddError("API Error", fmt.Sprintf("No UUID found in API response. Full response: %s", string(body)))
+		resp.Diagnostics.AddError("API Error", fmt.Sprintf("No UUID found in API response. Full response: %v", result))
 		return
 	}
 
 	// Apply the configuration
-	applyURL := fmt.Sprintf("%s/api/firewall/filter/apply", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "firewall/filter/apply", &resp.Diagnostics)
+
+	resolveSequence(ctx, r.client, "firewall/filter/getRule/"+data.ID.ValueString(), "rule", &data.Sequence, &resp.Diagnostics)
 
 	tflog.Trace(ctx, "created firewall rule resource")
 
@@ -405,35 +392,115 @@ func (r *FirewallRuleResource) Read(ctx context.Context, req resource.ReadReques
 	}
 
 	// Get rule by UUID
-	url := fmt.Sprintf("%s/api/firewall/filter/getRule/%s", r.client.Host, data.ID.ValueString())
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create request: %s", err))
-		return
-	}
-
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
+	rule, err := r.client.GetItem(ctx, "firewall/filter/getRule/"+data.ID.ValueString(), "rule")
 	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read rule: %s", err))
+		addClientError(&resp.Diagnostics, "Unable to read rule", err, firewallRuleFields)
 		return
 	}
-	defer httpResp.Body.Close()
-
-	if httpResp.StatusCode == 404 {
+	if rule == nil {
 		resp.State.RemoveResource(ctx)
 		return
 	}
 
-	if httpResp.StatusCode != 200 {
-		resp.Diagnostics.AddError("API Error", fmt.Sprintf("API returned status %d", httpResp.StatusCode))
+	r.refreshModel(ctx, &data, parseFirewallRule(rule), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// refreshModel copies the values OPNsense reports into data. Optional
+// attributes that were never configured stay null while OPNsense reports
+// their default, so neither import nor refresh invents a diff.
+func (r *FirewallRuleResource) refreshModel(ctx context.Context, data *FirewallRuleResourceModel, rule firewallRuleValues, diags *diag.Diagnostics) {
+	data.Description = types.StringValue(rule.Description)
+	if rule.HasSequence {
+		data.Sequence = types.Int64Value(rule.Sequence)
+	}
+	data.Interface = refreshString(data.Interface, rule.Interface, "")
+	data.Direction = refreshString(data.Direction, rule.Direction, "in")
+	data.IPProtocol = refreshString(data.IPProtocol, rule.IPProtocol, "inet")
+	if !strings.EqualFold(data.Protocol.ValueString(), rule.Protocol) {
+		data.Protocol = types.StringValue(rule.Protocol)
+	}
+	if data.SourceNet.ValueString() != rule.SourceNet {
+		data.SourceNet = types.StringValue(rule.SourceNet)
+	}
+	data.SourcePort = refreshString(data.SourcePort, rule.SourcePort, "")
+	if data.DestNet.ValueString() != rule.DestNet {
+		data.DestNet = types.StringValue(rule.DestNet)
+	}
+	data.DestPort = refreshString(data.DestPort, rule.DestPort, "")
+	data.Action = refreshString(data.Action, rule.Action, "pass")
+	data.Enabled = refreshBool(data.Enabled, rule.Enabled, true)
+	data.Log = refreshBool(data.Log, rule.Log, false)
+	data.Quick = refreshBool(data.Quick, rule.Quick, true)
+	data.SourceNot = refreshBool(data.SourceNot, rule.SourceNot, false)
+
+	// destination_not is written from either invert or the deprecated
+	// destination_not attribute; report it on whichever one is in use.
+	if !data.DestinationNot.IsNull() {
+		data.DestinationNot = types.BoolValue(rule.DestinationNot)
+	} else {
+		data.Invert = refreshBool(data.Invert, rule.DestinationNot, false)
+	}
+
+	data.Categories = refreshStringList(ctx, data.Categories, rule.Categories, diags)
+}
+
+// firewallRuleValues is the decoded form of the rule object returned by
+// firewall/filter/getRule.
+type firewallRuleValues struct {
+	Description    string
+	Sequence       int64
+	HasSequence    bool
+	Interface      string
+	Direction      string
+	IPProtocol     string
+	Protocol       string
+	SourceNet      string
+	SourcePort     string
+	SourceNot      bool
+	DestNet        string
+	DestPort       string
+	DestinationNot bool
+	Action         string
+	Enabled        bool
+	Log            bool
+	Quick          bool
+	Categories     []string
+}
+
+func parseFirewallRule(rule map[string]interface{}) firewallRuleValues {
+	v := firewallRuleValues{
+		Description:    stringValue(rule["description"]),
+		Interface:      selectedOption(rule["interface"]),
+		Direction:      stringValue(rule["direction"]),
+		IPProtocol:     stringValue(rule["ipprotocol"]),
+		Protocol:       stringValue(rule["protocol"]),
+		SourceNet:      stringValue(rule["source_net"]),
+		SourcePort:     stringValue(rule["source_port"]),
+		SourceNot:      boolValue(rule["source_not"]),
+		DestNet:        stringValue(rule["destination_net"]),
+		DestPort:       stringValue(rule["destination_port"]),
+		DestinationNot: boolValue(rule["destination_not"]),
+		Action:         stringValue(rule["action"]),
+		Enabled:        boolValue(rule["enabled"]),
+		Log:            boolValue(rule["log"]),
+		Quick:          boolValue(rule["quick"]),
+	}
+	v.Sequence, v.HasSequence = int64Value(rule["sequence"])
+
+	// Older releases name the field "category", 26.1 uses "categories"
+	if categories, ok := rule["categories"]; ok {
+		v.Categories = selectedOptions(categories)
+	} else {
+		v.Categories = selectedOptions(rule["category"])
+	}
+	return v
+}
+
 func (r *FirewallRuleResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	var data FirewallRuleResourceModel
 
@@ -446,108 +513,66 @@ func (r *FirewallRuleResource) Update(ctx context.Context, req resource.UpdateRe
 	// Similar to Create, but use setRule endpoint with UUID
 	ruleData := map[string]interface{}{
 		"rule": map[string]interface{}{
-			"description": data.Description.ValueString(),
-			"source_net":  data.SourceNet.ValueString(),
+			"description":     data.Description.ValueString(),
+			"source_net":      data.SourceNet.ValueString(),
 			"destination_net": data.DestNet.ValueString(),
-			"protocol":    data.Protocol.ValueString(),
+			"protocol":        data.Protocol.ValueString(),
 		},
 	}
 
 	// Add sequence if provided
-	if !data.Sequence.IsNull() {
+	if !data.Sequence.IsNull() && !data.Sequence.IsUnknown() {
 		ruleData["rule"].(map[string]interface{})["sequence"] = fmt.Sprintf("%d", data.Sequence.ValueInt64())
 	}
 
-	// Add optional fields (same as Create)
-	if !data.Interface.IsNull() {
-		ruleData["rule"].(map[string]interface{})["interface"] = data.Interface.ValueString()
-	}
+	// Unlike Create, every optional field is sent: a value removed from the
+	// configuration goes back to the OPNsense default instead of keeping
+	// the old value, which Read would report as drift forever.
+	rule := ruleData["rule"].(map[string]interface{})
+	rule["interface"] = data.Interface.ValueString()
+	rule["direction"] = "in"
 	if !data.Direction.IsNull() {
-		ruleData["rule"].(map[string]interface{})["direction"] = data.Direction.ValueString()
+		rule["direction"] = data.Direction.ValueString()
 	}
+	rule["ipprotocol"] = "inet"
 	if !data.IPProtocol.IsNull() {
-		ruleData["rule"].(map[string]interface{})["ipprotocol"] = data.IPProtocol.ValueString()
-	}
-	if !data.SourcePort.IsNull() {
-		ruleData["rule"].(map[string]interface{})["source_port"] = data.SourcePort.ValueString()
-	}
-	if !data.DestPort.IsNull() {
-		ruleData["rule"].(map[string]interface{})["destination_port"] = data.DestPort.ValueString()
+		rule["ipprotocol"] = data.IPProtocol.ValueString()
 	}
+	rule["source_port"] = data.SourcePort.ValueString()
+	rule["destination_port"] = data.DestPort.ValueString()
+	rule["action"] = "pass"
 	if !data.Action.IsNull() {
-		ruleData["rule"].(map[string]interface{})["action"] = data.Action.ValueString()
-	}
-	if !data.Enabled.IsNull() {
-		if data.Enabled.ValueBool() {
-			ruleData["rule"].(map[string]interface{})["enabled"] = "1"
-		} else {
-			ruleData["rule"].(map[string]interface{})["enabled"] = "0"
-		}
-	}
-	if !data.Log.IsNull() {
-		if data.Log.ValueBool() {
-			ruleData["rule"].(map[string]interface{})["log"] = "1"
-		} else {
-			ruleData["rule"].(map[string]interface{})["log"] = "0"
-		}
-	}
-	if !data.Quick.IsNull() {
-		if data.Quick.ValueBool() {
-			ruleData["rule"].(map[string]interface{})["quick"] = "1"
-		} else {
-			ruleData["rule"].(map[string]interface{})["quick"] = "0"
-		}
-	}
-	if !data.Invert.IsNull() {
-		if data.Invert.ValueBool() {
-			ruleData["rule"].(map[string]interface{})["destination_not"] = "1"
-		} else {
-			ruleData["rule"].(map[string]interface{})["destination_not"] = "0"
-		}
-	}
-	// Handle deprecated but still functional destination_not field
+		rule["action"] = data.Action.ValueString()
+	}
+	rule["enabled"] = boolFlag(data.Enabled, true)
+	rule["log"] = boolFlag(data.Log, false)
+	rule["quick"] = boolFlag(data.Quick, true)
+	// destination_not is written from invert or the deprecated
+	// destination_not attribute, which takes precedence
+	rule["destination_not"] = boolFlag(data.Invert, false)
 	if !data.DestinationNot.IsNull() {
-		if data.DestinationNot.ValueBool() {
-			ruleData["rule"].(map[string]interface{})["destination_not"] = "1"
-		} else {
-			ruleData["rule"].(map[string]interface{})["destination_not"] = "0"
-		}
-	}
-	// Handle deprecated but still functional source_not field
-	if !data.SourceNot.IsNull() {
-		if data.SourceNot.ValueBool() {
-			ruleData["rule"].(map[string]interface{})["source_not"] = "1"
-		} else{
-			ruleData["rule"].(map[string]interface{})["source_not"] = "0"
-		}
+		rule["destination_not"] = boolFlag(data.DestinationNot, false)
 	}
+	rule["source_not"] = boolFlag(data.SourceNot, false)
+	// An empty selection clears categories removed from the configuration
+	var categories []string
 	if !data.Categories.IsNull() {
-		var categories []string
 		resp.Diagnostics.Append(data.Categories.ElementsAs(ctx, &categories, false)...)
-		if !resp.Diagnostics.HasError() && len(categories) > 0 {
-			ruleData["rule"].(map[string]interface{})["category"] = strings.Join(categories, ",")
+		if resp.Diagnostics.HasError() {
+			return
 		}
 	}
+	rule["category"] = strings.Join(categories, ",")
 
-	jsonData, _ := json.Marshal(ruleData)
-
-	url := fmt.Sprintf("%s/api/firewall/filter/setRule/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update rule: %s", err))
+	if err := r.client.Post(ctx, "firewall/filter/setRule/"+data.ID.ValueString(), ruleData, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to update rule", err, firewallRuleFields)
 		return
 	}
-	defer httpResp.Body.Close()
 
 	// Apply the configuration
-	applyURL := fmt.Sprintf("%s/api/firewall/filter/apply", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "firewall/filter/apply", &resp.Diagnostics)
+
+	resolveSequence(ctx, r.client, "firewall/filter/getRule/"+data.ID.ValueString(), "rule", &data.Sequence, &resp.Diagnostics)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -561,24 +586,15 @@ func (r *FirewallRuleResource) Delete(ctx context.Context, req resource.DeleteRe
 		return
 	}
 
-	url := fmt.Sprintf("%s/api/firewall/filter/delRule/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, nil)
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete rule: %s", err))
+	if err := r.client.Post(ctx, "firewall/filter/delRule/"+data.ID.ValueString(), nil, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to delete rule", err, firewallRuleFields)
 		return
 	}
-	defer httpResp.Body.Close()
 
 	// Apply the configuration
-	applyURL := fmt.Sprintf("%s/api/firewall/filter/apply", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "firewall/filter/apply", &resp.Diagnostics)
 }
 
 func (r *FirewallRuleResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
-}
\ No newline at end of file
+}
@@ -0,0 +1,266 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestFirewallRuleResource(t *testing.T) {
+	f := newFakeOPNsense(t)
+	var id string
+
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: `
+resource "opnsense_firewall_category" "web" {
+  name = "web"
+}
+
+resource "opnsense_firewall_rule" "test" {
+  description      = "Allow HTTPS"
+  interface        = "lan"
+  protocol         = "TCP"
+  source_net       = "any"
+  destination_net  = "10.0.0.10"
+  destination_port = "443"
+  categories       = [opnsense_firewall_category.web.id]
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				captureID("opnsense_firewall_rule.test", &id),
+				resource.TestCheckResourceAttr("opnsense_firewall_rule.test", "sequence", "1"),
+				resource.TestCheckResourceAttr("opnsense_firewall_rule.test", "categories.#", "1"),
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "destination_port", "443"),
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "action", "pass"),
+				checkApplied(f, filterApplyEndpoint),
+			),
+		},
+		resource.TestStep{
+			Config: `
+resource "opnsense_firewall_category" "web" {
+  name = "web"
+}
+
+resource "opnsense_firewall_rule" "test" {
+  description      = "Block HTTPS"
+  interface        = "lan"
+  protocol         = "TCP"
+  source_net       = "any"
+  destination_net  = "10.0.0.10"
+  destination_port = "443"
+  action           = "block"
+  log              = true
+  sequence         = 20
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("opnsense_firewall_rule.test", "sequence", "20"),
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "action", "block"),
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "log", "1"),
+				// Categories removed from the configuration are cleared.
+				resource.TestCheckNoResourceAttr("opnsense_firewall_rule.test", "categories"),
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "category", ""),
+			),
+		},
+		resource.TestStep{
+			ResourceName:      "opnsense_firewall_rule.test",
+			ImportState:       true,
+			ImportStateVerify: true,
+		},
+		resource.TestStep{
+			PreConfig: func() {
+				f.Update("filter.rule", id, map[string]interface{}{"destination_port": "8443"})
+			},
+			Config: `
+resource "opnsense_firewall_category" "web" {
+  name = "web"
+}
+
+resource "opnsense_firewall_rule" "test" {
+  description      = "Block HTTPS"
+  interface        = "lan"
+  protocol         = "TCP"
+  source_net       = "any"
+  destination_net  = "10.0.0.10"
+  destination_port = "443"
+  action           = "block"
+  log              = true
+  sequence         = 20
+}
+`,
+			PlanOnly:           true,
+			ExpectNonEmptyPlan: true,
+		},
+	)
+}
+
+func TestFirewallRuleResourceUnsetAttributes(t *testing.T) {
+	f := newFakeOPNsense(t)
+	unset := `
+resource "opnsense_firewall_rule" "test" {
+  description     = "Allow all"
+  protocol        = "TCP"
+  source_net      = "any"
+  destination_net = "10.0.0.10"
+}
+`
+
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: `
+resource "opnsense_firewall_rule" "test" {
+  description      = "Allow all"
+  interface        = "lan"
+  direction        = "out"
+  ip_protocol      = "inet6"
+  protocol         = "TCP"
+  source_net       = "any"
+  source_port      = "1024"
+  destination_net  = "10.0.0.10"
+  destination_port = "443"
+  action           = "block"
+  enabled          = false
+  log              = true
+  quick            = false
+  invert           = true
+}
+`,
+		},
+		resource.TestStep{
+			// Attributes removed from the configuration go back to their
+			// defaults instead of keeping the old value.
+			Config: unset,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "interface", ""),
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "direction", "in"),
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "ipprotocol", "inet"),
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "source_port", ""),
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "destination_port", ""),
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "action", "pass"),
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "enabled", "1"),
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "log", "0"),
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "quick", "1"),
+				checkFakeField(f, "filter.rule", "opnsense_firewall_rule.test", "destination_not", "0"),
+			),
+		},
+		resource.TestStep{
+			Config:   unset,
+			PlanOnly: true,
+		},
+	)
+}
+
+func TestFirewallRuleResourceValidation(t *testing.T) {
+	f := newFakeOPNsense(t)
+
+	fakeTest(t, f, resource.TestStep{
+		Config: `
+resource "opnsense_firewall_rule" "test" {
+  description      = "Bad port"
+  protocol         = "TCP"
+  source_net       = "any"
+  destination_net  = "any"
+  destination_port = "99999"
+}
+`,
+		ExpectError: regexp.MustCompile(`(?s)destination_port.*valid\s+port\s+number`),
+	})
+}
+
+func TestFirewallRuleResourceSafeApply(t *testing.T) {
+	f := newFakeOPNsense(t)
+	f.ProviderSettings = "safe_apply = true"
+
+	fakeTest(t, f, resource.TestStep{
+		Config: `
+resource "opnsense_firewall_rule" "test" {
+  description     = "Allow DNS"
+  protocol        = "UDP"
+  source_net      = "any"
+  destination_net = "any"
+}
+`,
+		Check: func(*terraform.State) error {
+			for _, want := range []string{"POST firewall/filter/savepoint", "POST firewall/filter/cancelRollback/1700000000.1234"} {
+				found := false
+				for _, req := range f.Requests() {
+					found = found || req == want
+				}
+				if !found {
+					return fmt.Errorf("request %q not sent", want)
+				}
+			}
+			return nil
+		},
+	})
+}
+
+func TestSafeApplyCheckUsesNewConnections(t *testing.T) {
+	var connections atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeFakeJSON(w, http.StatusOK, map[string]interface{}{})
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(ClientConfig{Host: srv.URL, ApiKey: fakeAPIKey, ApiSecret: fakeAPISecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Regular requests share a kept-alive connection...
+	for i := 0; i < 2; i++ {
+		if err := client.Get(ctx, "firewall/filter/getRule", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// ...which the reachability check must not rely on.
+	for i := 0; i < 2; i++ {
+		if err := client.checkReachable(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := connections.Load(); got != 3 {
+		t.Errorf("opened %d connections, want 1 shared and 2 for the checks", got)
+	}
+}
+
+func TestSafeApplyCheckStopsAtDeadline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+
+	// timeout_seconds = 0 must not let a hanging probe outlast the deadline.
+	client, err := NewClient(ClientConfig{Host: srv.URL, ApiKey: fakeAPIKey, ApiSecret: fakeAPISecret, Timeout: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := client.checkReachable(ctx); err == nil {
+		t.Fatal("check of a hanging API succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("check took %s, want it to stop at the 1s deadline", elapsed)
+	}
+}
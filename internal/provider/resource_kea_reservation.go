@@ -4,8 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
@@ -19,6 +17,7 @@ import (
 
 var _ resource.Resource = &KeaReservationResource{}
 var _ resource.ResourceWithImportState = &KeaReservationResource{}
+var _ resource.ResourceWithModifyPlan = &KeaReservationResource{}
 
 func NewKeaReservationResource() resource.Resource {
 	return &KeaReservationResource{}
@@ -37,6 +36,15 @@ type KeaReservationResourceModel struct {
 	Description types.String `tfsdk:"description"`
 }
 
+// keaReservationFields maps OPNsense reservation fields to resource attributes.
+var keaReservationFields = map[string]string{
+	"subnet":      "subnet",
+	"ip_address":  "ip_address",
+	"hw_address":  "hw_address",
+	"hostname":    "hostname",
+	"description": "description",
+}
+
 func (r *KeaReservationResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_kea_reservation"
 }
@@ -94,6 +102,14 @@ func (r *KeaReservationResource) Configure(ctx context.Context, req resource.Con
 	r.client = client
 }
 
+// ModifyPlan fails the plan when the firewall does not provide Kea DHCP.
+func (r *KeaReservationResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	r.client.requireSubsystem(subsystemKea, &resp.Diagnostics)
+}
+
 func (r *KeaReservationResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var data KeaReservationResourceModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
@@ -120,127 +136,16 @@ func (r *KeaReservationResource) Create(ctx context.Context, req resource.Create
 
 	// Log the request for debugging
 	tflog.Debug(ctx, "Creating Kea reservation", map[string]any{
-		"endpoint": "/api/kea/dhcpv4/add_reservation",
+		"endpoint": "kea/dhcpv4/add_reservation",
 		"request":  string(jsonData),
 	})
 
-	url := fmt.Sprintf("%s/api/kea/dhcpv4/add_reservation", r.client.Host)
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create reservation: %s", err))
-		return
-	}
-	defer httpResp.Body.Close()
-
-	body, err := io.ReadAll(httpResp.Body)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read response: %s", err))
-		return
-	}
-
-	// Log raw response
-	tflog.Debug(ctx, "Kea API Response", map[string]any{
-		"status_code": httpResp.StatusCode,
-		"body":        string(body),
-	})
-
-	if httpResp.StatusCode != 200 {
-		resp.Diagnostics.AddError("API Error", fmt.Sprintf("API returned status %d: %s", httpResp.StatusCode, string(body)))
-		return
-	}
-
-	// Check if response is empty or just whitespace
-	if len(strings.TrimSpace(string(body))) == 0 {
-		resp.Diagnostics.AddError("API Error", "API returned empty response")
-		return
-	}
-
-	// Try to determine what kind of response we got
-	firstChar := strings.TrimSpace(string(body))[0]
-	
-	if firstChar == '[' {
-		// It's an array response - likely validation errors or empty response
-		var resultArray []interface{}
-		if err := json.Unmarshal(body, &resultArray); err != nil {
-			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to parse array response: %s\nRaw response: %s", err, string(body)))
-			return
-		}
-		
-		if len(resultArray) == 0 {
-			resp.Diagnostics.AddError(
-				"Kea DHCP API Error",
-				"API returned empty array [].\n\n"+
-				"This typically means:\n"+
-				"1. The Kea DHCP plugin is not installed or enabled in OPNsense\n"+
-				"2. The subnet UUID referenced doesn't exist\n"+
-				"3. Request validation failed\n\n"+
-				"To fix:\n"+
-				"- In OPNsense GUI: System > Firmware > Plugins\n"+
-				"- Install 'os-kea-dhcp' plugin if not already installed\n"+
-				"- Ensure the referenced subnet exists first\n"+
-				"- Check Services > Kea DHCPv4 to ensure it's configured\n\n"+
-				"Request sent: "+string(jsonData),
-			)
-			return
-		}
-		
-		// Try to extract error messages from array
-		var errorMessages []string
-		for _, item := range resultArray {
-			if errMap, ok := item.(map[string]interface{}); ok {
-				if msg, ok := errMap["message"].(string); ok {
-					errorMessages = append(errorMessages, msg)
-				}
-			}
-		}
-		
-		if len(errorMessages) > 0 {
-			resp.Diagnostics.AddError("API Validation Error", fmt.Sprintf("API returned errors: %s", strings.Join(errorMessages, ", ")))
-		} else {
-			resp.Diagnostics.AddError("API Error", fmt.Sprintf("API returned unexpected array response: %s", string(body)))
-		}
-		return
-	}
-
 	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to parse response: %s\nRaw response: %s", err, string(body)))
+	if err := r.client.Post(ctx, "kea/dhcpv4/add_reservation", reservationData, &result); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to create reservation", err, keaReservationFields)
 		return
 	}
 
-	// Check for validation errors in result
-	if resultStatus, ok := result["result"].(string); ok && resultStatus == "failed" {
-		var errorMsgs []string
-		if validations, ok := result["validations"].(map[string]interface{}); ok {
-			for field, errors := range validations {
-				if errList, ok := errors.([]interface{}); ok {
-					for _, err := range errList {
-						if errStr, ok := err.(string); ok {
-							errorMsgs = append(errorMsgs, fmt.Sprintf("%s: %s", field, errStr))
-						}
-					}
-				} else if errStr, ok := errors.(string); ok {
-					errorMsgs = append(errorMsgs, fmt.Sprintf("%s: %s", field, errStr))
-				}
-			}
-		}
-		if len(errorMsgs) > 0 {
-			resp.Diagnostics.AddError("API Validation Failed", fmt.Sprintf("Validation errors:\n- %s", strings.Join(errorMsgs, "\n- ")))
-		} else {
-			resp.Diagnostics.AddError("API Error", fmt.Sprintf("API returned failed status: %s", string(body)))
-		}
-		return
-	}
-
-	// Log the full response for debugging
-	if resultJSON, err := json.MarshalIndent(result, "", "  "); err == nil {
-		tflog.Debug(ctx, "API Response", map[string]any{"response": string(resultJSON)})
-	}
-
 	// Try to extract UUID from various possible response formats
 	var uuid string
 	if uuidVal, ok := result["uuid"].(string); ok {
@@ -258,15 +163,12 @@ func (r *KeaReservationResource) Create(ctx context.Context, req resource.Create
 	if uuid != "" {
 		data.ID = types.StringValue(uuid)
 	} else {
-		resp.Diagnostics.AddError("API Error", fmt.Sprintf("No UUID found in API response. Response: %s", string(body)))
+		resp.Diagnostics.AddError("API Error", fmt.Sprintf("No UUID found in API response. Response: %v", result))
 		return
 	}
 
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/kea/service/reconfigure", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "kea/service/reconfigure", &resp.Diagnostics)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -278,39 +180,17 @@ func (r *KeaReservationResource) Read(ctx context.Context, req resource.ReadRequ
 		return
 	}
 
-	url := fmt.Sprintf("%s/api/kea/dhcpv4/get_reservation/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read reservation: %s", err))
-		return
-	}
-	defer httpResp.Body.Close()
-
-	if httpResp.StatusCode == 404 {
+	var body json.RawMessage
+	err := r.client.Get(ctx, "kea/dhcpv4/get_reservation/"+data.ID.ValueString(), &body)
+	if IsNotFound(err) {
 		resp.State.RemoveResource(ctx)
 		return
 	}
-
-	if httpResp.StatusCode != 200 {
-		resp.Diagnostics.AddError("API Error", fmt.Sprintf("API returned status %d", httpResp.StatusCode))
-		return
-	}
-
-	body, err := io.ReadAll(httpResp.Body)
 	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read response: %s", err))
+		addClientError(&resp.Diagnostics, "Unable to read reservation", err, keaReservationFields)
 		return
 	}
 
-	// Log raw response
-	tflog.Debug(ctx, "Kea reservation Read response", map[string]any{
-		"status_code": httpResp.StatusCode,
-		"body":        string(body),
-	})
-
 	// Check if response is empty
 	if len(strings.TrimSpace(string(body))) == 0 {
 		// Empty response might mean the reservation doesn't exist anymore
@@ -320,7 +200,7 @@ func (r *KeaReservationResource) Read(ctx context.Context, req resource.ReadRequ
 
 	// Try to determine what kind of response we got
 	firstChar := strings.TrimSpace(string(body))[0]
-	
+
 	if firstChar == '[' {
 		// Array response - likely means resource doesn't exist or error
 		tflog.Warn(ctx, "Kea reservation returned array, removing from state", map[string]any{
@@ -380,36 +260,13 @@ func (r *KeaReservationResource) Update(ctx context.Context, req resource.Update
 		reservationData["reservation"].(map[string]interface{})["description"] = data.Description.ValueString()
 	}
 
-	jsonData, _ := json.Marshal(reservationData)
-
-	url := fmt.Sprintf("%s/api/kea/dhcpv4/set_reservation/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update reservation: %s", err))
-		return
-	}
-	defer httpResp.Body.Close()
-
-	body, err := io.ReadAll(httpResp.Body)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read response: %s", err))
-		return
-	}
-
-	if httpResp.StatusCode != 200 {
-		resp.Diagnostics.AddError("API Error", fmt.Sprintf("API returned status %d: %s", httpResp.StatusCode, string(body)))
+	if err := r.client.Post(ctx, "kea/dhcpv4/set_reservation/"+data.ID.ValueString(), reservationData, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to update reservation", err, keaReservationFields)
 		return
 	}
 
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/kea/service/reconfigure", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "kea/service/reconfigure", &resp.Diagnostics)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -421,24 +278,15 @@ func (r *KeaReservationResource) Delete(ctx context.Context, req resource.Delete
 		return
 	}
 
-	url := fmt.Sprintf("%s/api/kea/dhcpv4/del_reservation/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, nil)
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete reservation: %s", err))
+	if err := r.client.Post(ctx, "kea/dhcpv4/del_reservation/"+data.ID.ValueString(), nil, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to delete reservation", err, keaReservationFields)
 		return
 	}
-	defer httpResp.Body.Close()
 
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/kea/service/reconfigure", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "kea/service/reconfigure", &resp.Diagnostics)
 }
 
 func (r *KeaReservationResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
-}
\ No newline at end of file
+}
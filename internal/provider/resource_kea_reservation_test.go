@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+const testKeaSubnetConfig = `
+resource "opnsense_kea_subnet" "lan" {
+  subnet       = "192.168.1.0/24"
+  pools        = "192.168.1.100-192.168.1.200"
+  description  = "LAN"
+  auto_collect = true
+}
+`
+
+func TestKeaReservationResource(t *testing.T) {
+	f := newFakeOPNsense(t)
+
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: testKeaSubnetConfig + `
+resource "opnsense_kea_reservation" "test" {
+  subnet      = opnsense_kea_subnet.lan.id
+  ip_address  = "192.168.1.10"
+  hw_address  = "00:11:22:33:44:55"
+  hostname    = "printer"
+  description = "Office printer"
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkFakeField(f, "kea.reservation", "opnsense_kea_reservation.test", "hw_address", "00:11:22:33:44:55"),
+				checkApplied(f, "kea/service/reconfigure"),
+			),
+		},
+		resource.TestStep{
+			Config: testKeaSubnetConfig + `
+resource "opnsense_kea_reservation" "test" {
+  subnet      = opnsense_kea_subnet.lan.id
+  ip_address  = "192.168.1.11"
+  hw_address  = "00:11:22:33:44:55"
+  hostname    = "printer"
+  description = "Office printer"
+}
+`,
+			Check: checkFakeField(f, "kea.reservation", "opnsense_kea_reservation.test", "ip_address", "192.168.1.11"),
+		},
+	)
+}
+
+func TestKeaReservationResourceValidation(t *testing.T) {
+	f := newFakeOPNsense(t)
+
+	fakeTest(t, f, resource.TestStep{
+		Config: testKeaSubnetConfig + `
+resource "opnsense_kea_reservation" "test" {
+  subnet      = opnsense_kea_subnet.lan.id
+  ip_address  = "192.168.1.10"
+  hw_address  = "not-a-mac"
+  hostname    = "printer"
+  description = "Office printer"
+}
+`,
+		ExpectError: regexp.MustCompile(`valid\s+MAC\s+address`),
+	})
+}
@@ -2,13 +2,8 @@ package provider
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
 	"strings"
 
-	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -19,6 +14,7 @@ import (
 
 var _ resource.Resource = &KeaSubnetResource{}
 var _ resource.ResourceWithImportState = &KeaSubnetResource{}
+var _ resource.ResourceWithModifyPlan = &KeaSubnetResource{}
 
 func NewKeaSubnetResource() resource.Resource {
 	return &KeaSubnetResource{}
@@ -37,6 +33,15 @@ type KeaSubnetResourceModel struct {
 	Description types.String `tfsdk:"description"`
 }
 
+// keaSubnetFields maps OPNsense subnet4 fields to resource attributes.
+var keaSubnetFields = map[string]string{
+	"subnet":                  "subnet",
+	"pools":                   "pools",
+	"description":             "description",
+	"option_data_autocollect": "auto_collect",
+	"option_data":             "option_data",
+}
+
 func (r *KeaSubnetResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_kea_subnet"
 }
@@ -45,7 +50,7 @@ func (r *KeaSubnetResource) Schema(ctx context.Context, req resource.SchemaReque
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
-				Computed: true,
+				Computed:      true,
 				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
 			},
 			"subnet":      schema.StringAttribute{Required: true},
@@ -64,7 +69,9 @@ func (r *KeaSubnetResource) Schema(ctx context.Context, req resource.SchemaReque
 }
 
 func (r *KeaSubnetResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
-	if req.ProviderData == nil { return }
+	if req.ProviderData == nil {
+		return
+	}
 	client, ok := req.ProviderData.(*Client)
 	if !ok {
 		resp.Diagnostics.AddError("Type Error", "Expected *Client")
@@ -73,41 +80,55 @@ func (r *KeaSubnetResource) Configure(ctx context.Context, req resource.Configur
 	r.client = client
 }
 
+// ModifyPlan fails the plan when the firewall does not provide Kea DHCP.
+func (r *KeaSubnetResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	r.client.requireSubsystem(subsystemKea, &resp.Diagnostics)
+}
+
 func (r *KeaSubnetResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var data KeaSubnetResourceModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
-	if resp.Diagnostics.HasError() { return }
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	payload := r.mapToPayload(ctx, &data)
-	jsonData, _ := json.Marshal(payload)
-
-	url := fmt.Sprintf("%s/api/kea/dhcpv4/add_subnet", r.client.Host)
-	body := r.doRequest(ctx, "POST", url, jsonData, &resp.Diagnostics)
-	if resp.Diagnostics.HasError() { return }
 
 	var result map[string]interface{}
-	json.Unmarshal(body, &result)
+	if err := r.client.Post(ctx, "kea/dhcpv4/add_subnet", payload, &result); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to create subnet", err, keaSubnetFields)
+		return
+	}
 
 	// OPNsense returns UUID in 'uuid' field
 	if uuid, ok := result["uuid"].(string); ok {
 		data.ID = types.StringValue(uuid)
 	}
 
-	r.reconfigureService(ctx)
+	r.client.applyChanges(ctx, "kea/service/reconfigure", &resp.Diagnostics)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *KeaSubnetResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
 	var data KeaSubnetResourceModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
-	if resp.Diagnostics.HasError() { return }
-
-	url := fmt.Sprintf("%s/api/kea/dhcpv4/get_subnet/%s", r.client.Host, data.ID.ValueString())
-	body := r.doRequest(ctx, "GET", url, nil, &resp.Diagnostics)
-	if resp.Diagnostics.HasError() || body == nil { return }
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	var result map[string]interface{}
-	json.Unmarshal(body, &result)
+	err := r.client.Get(ctx, "kea/dhcpv4/get_subnet/"+data.ID.ValueString(), &result)
+	if IsNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Unable to read subnet", err, keaSubnetFields)
+		return
+	}
 
 	if subnetData, ok := result["subnet4"].(map[string]interface{}); ok {
 		data.Subnet = types.StringValue(subnetData["subnet"].(string))
@@ -121,24 +142,34 @@ func (r *KeaSubnetResource) Read(ctx context.Context, req resource.ReadRequest,
 func (r *KeaSubnetResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	var data KeaSubnetResourceModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
-	if resp.Diagnostics.HasError() { return }
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	payload := r.mapToPayload(ctx, &data)
-	jsonData, _ := json.Marshal(payload)
 
-	url := fmt.Sprintf("%s/api/kea/dhcpv4/set_subnet/%s", r.client.Host, data.ID.ValueString())
-	r.doRequest(ctx, "POST", url, jsonData, &resp.Diagnostics)
-	
-	r.reconfigureService(ctx)
+	if err := r.client.Post(ctx, "kea/dhcpv4/set_subnet/"+data.ID.ValueString(), payload, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to update subnet", err, keaSubnetFields)
+		return
+	}
+
+	r.client.applyChanges(ctx, "kea/service/reconfigure", &resp.Diagnostics)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *KeaSubnetResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
 	var data KeaSubnetResourceModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
-	url := fmt.Sprintf("%s/api/kea/dhcpv4/del_subnet/%s", r.client.Host, data.ID.ValueString())
-	r.doRequest(ctx, "POST", url, nil, &resp.Diagnostics)
-	r.reconfigureService(ctx)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.Post(ctx, "kea/dhcpv4/del_subnet/"+data.ID.ValueString(), nil, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to delete subnet", err, keaSubnetFields)
+		return
+	}
+
+	r.client.applyChanges(ctx, "kea/service/reconfigure", &resp.Diagnostics)
 }
 
 func (r *KeaSubnetResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
@@ -150,9 +181,13 @@ func (r *KeaSubnetResource) mapToPayload(ctx context.Context, data *KeaSubnetRes
 		"subnet": data.Subnet.ValueString(),
 	}
 
-	if !data.Pools.IsNull() { subnet4["pools"] = data.Pools.ValueString() }
-	if !data.Description.IsNull() { subnet4["description"] = data.Description.ValueString() }
-	
+	if !data.Pools.IsNull() {
+		subnet4["pools"] = data.Pools.ValueString()
+	}
+	if !data.Description.IsNull() {
+		subnet4["description"] = data.Description.ValueString()
+	}
+
 	// Convert bool to OPNsense string "0" or "1"
 	if !data.AutoCollect.IsNull() && !data.AutoCollect.ValueBool() {
 		subnet4["option_data_autocollect"] = "0"
@@ -165,7 +200,7 @@ func (r *KeaSubnetResource) mapToPayload(ctx context.Context, data *KeaSubnetRes
 	if !data.Option.IsNull() && !data.Option.IsUnknown() {
 		var optionMap map[string]string
 		data.Option.ElementsAs(ctx, &optionMap, false)
-		
+
 		optionData := make(map[string]interface{})
 		for k, v := range optionMap {
 			// Convert hyphenated names to underscores
@@ -178,38 +213,5 @@ func (r *KeaSubnetResource) mapToPayload(ctx context.Context, data *KeaSubnetRes
 		subnet4["option_data"] = optionData
 	}
 
-
 	return map[string]interface{}{"subnet4": subnet4}
 }
-
-func (r *KeaSubnetResource) doRequest(ctx context.Context, method, url string, body []byte, diags *diag.Diagnostics) []byte {
-	var reader io.Reader
-	if body != nil { reader = strings.NewReader(string(body)) }
-	req, _ := http.NewRequestWithContext(ctx, method, url, reader)
-	req.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	// Only set Content-Type if we have a body
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
-	
-	httpResp, err := r.client.client.Do(req)
-	if err != nil {
-		diags.AddError("Client Error", err.Error())
-		return nil
-	}
-	defer httpResp.Body.Close()
-	respBody, _ := io.ReadAll(httpResp.Body)
-	
-	if httpResp.StatusCode != 200 {
-		diags.AddError("API Error", fmt.Sprintf("Status %d: %s", httpResp.StatusCode, string(respBody)))
-		return nil
-	}
-	return respBody
-}
-
-func (r *KeaSubnetResource) reconfigureService(ctx context.Context) {
-	url := fmt.Sprintf("%s/api/kea/service/reconfigure", r.client.Host)
-	req, _ := http.NewRequestWithContext(ctx, "POST", url, nil)
-	req.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(req)
-}
\ No newline at end of file
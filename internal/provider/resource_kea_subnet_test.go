@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestKeaSubnetResource(t *testing.T) {
+	f := newFakeOPNsense(t)
+
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: `
+resource "opnsense_kea_subnet" "test" {
+  subnet       = "192.168.50.0/24"
+  pools        = "192.168.50.100-192.168.50.200"
+  description  = "Guests"
+  auto_collect = false
+  option_data = {
+    "domain-name-servers" = "192.168.50.1, 192.168.50.2"
+    "routers"             = "192.168.50.1"
+  }
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkFakeField(f, "kea.subnet", "opnsense_kea_subnet.test", "option_data_autocollect", "0"),
+				checkFakeItem(f, "kea.subnet", "opnsense_kea_subnet.test", func(item map[string]interface{}) error {
+					options, _ := item["option_data"].(map[string]interface{})
+					if got := fakeString(options["domain_name_servers"]); got != "192.168.50.1,192.168.50.2" {
+						return fmt.Errorf("domain_name_servers = %q", got)
+					}
+					return nil
+				}),
+				checkApplied(f, "kea/service/reconfigure"),
+			),
+		},
+		resource.TestStep{
+			Config: `
+resource "opnsense_kea_subnet" "test" {
+  subnet       = "192.168.50.0/24"
+  pools        = "192.168.50.50-192.168.50.200"
+  description  = "Guests"
+  auto_collect = true
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkFakeField(f, "kea.subnet", "opnsense_kea_subnet.test", "pools", "192.168.50.50-192.168.50.200"),
+				checkFakeField(f, "kea.subnet", "opnsense_kea_subnet.test", "option_data_autocollect", "1"),
+			),
+		},
+		resource.TestStep{
+			ResourceName:            "opnsense_kea_subnet.test",
+			ImportState:             true,
+			ImportStateVerify:       true,
+			ImportStateVerifyIgnore: []string{"option_data"},
+		},
+	)
+}
+
+func TestKeaSubnetResourceValidation(t *testing.T) {
+	f := newFakeOPNsense(t)
+
+	fakeTest(t, f, resource.TestStep{
+		Config: `
+resource "opnsense_kea_subnet" "test" {
+  subnet       = "192.168.50.0"
+  pools        = ""
+  description  = ""
+  auto_collect = true
+}
+`,
+		ExpectError: regexp.MustCompile(`valid\s+network\s+segment`),
+	})
+}
@@ -2,12 +2,10 @@ package provider
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -18,6 +16,7 @@ import (
 
 var _ resource.Resource = &NatDestinationResource{}
 var _ resource.ResourceWithImportState = &NatDestinationResource{}
+var _ resource.ResourceWithModifyPlan = &NatDestinationResource{}
 
 func NewNatDestinationResource() resource.Resource {
 	return &NatDestinationResource{}
@@ -40,8 +39,34 @@ type NatDestinationResourceModel struct {
 	TargetPort      types.String `tfsdk:"target_port"`
 	Description     types.String `tfsdk:"description"`
 	Log             types.Bool   `tfsdk:"log"`
+	FilterRule      types.String `tfsdk:"filter_rule_association"`
+	FilterRuleID    types.String `tfsdk:"filter_rule_id"`
 }
 
+// natDestinationFields maps OPNsense port forward fields to resource attributes.
+var natDestinationFields = map[string]string{
+	"enabled":     "enabled",
+	"interface":   "interface",
+	"protocol":    "protocol",
+	"source":      "source_net",
+	"src_port":    "source_port",
+	"destination": "destination_net",
+	"dst_port":    "destination_port",
+	"target":      "target_ip",
+	"local_port":  "target_port",
+	"description": "description",
+	"log":         "log",
+	"pass":        "filter_rule_association",
+}
+
+// Values of filter_rule_association, named after the choices in the GUI.
+const (
+	natFilterRuleAssociated   = "associated"
+	natFilterRuleUnassociated = "unassociated"
+	natFilterRulePass         = "pass"
+	natFilterRuleNone         = "none"
+)
+
 func (r *NatDestinationResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_nat_destination"
 }
@@ -102,6 +127,16 @@ func (r *NatDestinationResource) Schema(ctx context.Context, req resource.Schema
 				MarkdownDescription: "Log packets matching this rule",
 				Optional:            true,
 			},
+			"filter_rule_association": schema.StringAttribute{
+				MarkdownDescription: "Filter rule letting the forwarded traffic in: `associated` creates a pass rule that follows " +
+					"this NAT rule and is deleted with it, `unassociated` creates a pass rule once and leaves it alone afterwards, " +
+					"`pass` passes the traffic without a filter rule, `none` (default) adds nothing",
+				Optional: true,
+			},
+			"filter_rule_id": schema.StringAttribute{
+				MarkdownDescription: "UUID of the filter rule created for `associated` or `unassociated`",
+				Computed:            true,
+			},
 		},
 	}
 }
@@ -123,87 +158,98 @@ func (r *NatDestinationResource) Configure(ctx context.Context, req resource.Con
 	r.client = client
 }
 
-func (r *NatDestinationResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	var data NatDestinationResourceModel
-	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
-	if resp.Diagnostics.HasError() {
+// ModifyPlan fails the plan when the firewall does not provide destination NAT
+// and plans filter_rule_id, which is known unless a filter rule is created.
+func (r *NatDestinationResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
 		return
 	}
+	r.client.requireSubsystem(subsystemDNat, &resp.Diagnostics)
 
-	natData := map[string]interface{}{
-		"rule": map[string]interface{}{
-			"interface":   data.Interface.ValueString(),
-			"protocol":    data.Protocol.ValueString(),
-			"dst_port":    data.DestinationPort.ValueString(),
-			"target":      data.TargetIP.ValueString(),
-			"local_port":  data.TargetPort.ValueString(),
-		},
+	var association types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("filter_rule_association"), &association)...)
+	if resp.Diagnostics.HasError() || association.IsUnknown() {
+		return
 	}
 
-	if !data.Enabled.IsNull() {
-		if data.Enabled.ValueBool() {
-			natData["rule"].(map[string]interface{})["enabled"] = "1"
-		} else {
-			natData["rule"].(map[string]interface{})["enabled"] = "0"
-		}
+	var prior NatDestinationResourceModel
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
 	} else {
-		natData["rule"].(map[string]interface{})["enabled"] = "1"
+		prior.FilterRule = types.StringNull()
+		prior.FilterRuleID = types.StringNull()
 	}
-
-	if !data.SourceNet.IsNull() {
-		natData["rule"].(map[string]interface{})["source"] = data.SourceNet.ValueString()
-	}
-
-	if !data.SourcePort.IsNull() {
-		natData["rule"].(map[string]interface{})["src_port"] = data.SourcePort.ValueString()
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	if !data.DestinationNet.IsNull() {
-		natData["rule"].(map[string]interface{})["destination"] = data.DestinationNet.ValueString()
+	id := types.StringNull()
+	switch natFilterRuleMode(association) {
+	case natFilterRuleAssociated, natFilterRuleUnassociated:
+		r.client.requireSubsystem(subsystemFilter, &resp.Diagnostics)
+		if keepsFilterRule(prior) {
+			id = prior.FilterRuleID
+		} else {
+			id = types.StringUnknown()
+		}
+	case natFilterRulePass, natFilterRuleNone:
+	default:
+		resp.Diagnostics.AddAttributeError(
+			path.Root("filter_rule_association"),
+			"Invalid Filter Rule Association",
+			fmt.Sprintf("filter_rule_association must be %q, %q, %q or %q, got %q.",
+				natFilterRuleAssociated, natFilterRuleUnassociated, natFilterRulePass, natFilterRuleNone, association.ValueString()),
+		)
+		return
 	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("filter_rule_id"), id)...)
+}
 
-	if !data.Description.IsNull() {
-		natData["rule"].(map[string]interface{})["description"] = data.Description.ValueString()
+// natFilterRuleMode returns the filter_rule_association in effect.
+func natFilterRuleMode(association types.String) string {
+	if association.IsNull() {
+		return natFilterRuleNone
 	}
+	return association.ValueString()
+}
 
-	if !data.Log.IsNull() && data.Log.ValueBool() {
-		natData["rule"].(map[string]interface{})["log"] = "1"
+// keepsFilterRule reports whether a state still holds a filter rule that a
+// new plan with a created rule can take over.
+func keepsFilterRule(prior NatDestinationResourceModel) bool {
+	if prior.FilterRuleID.IsNull() || prior.FilterRuleID.IsUnknown() {
+		return false
 	}
+	mode := natFilterRuleMode(prior.FilterRule)
+	return mode == natFilterRuleAssociated || mode == natFilterRuleUnassociated
+}
 
-	jsonData, _ := json.Marshal(natData)
-
-	url := fmt.Sprintf("%s/api/firewall/d_nat/add_rule", r.client.Host)
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create NAT rule: %s", err))
+func (r *NatDestinationResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data NatDestinationResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
-	defer httpResp.Body.Close()
 
-	body, _ := io.ReadAll(httpResp.Body)
+	natData := natDestinationPayload(&data)
 
 	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to parse response: %s", err))
+	if err := r.client.Post(ctx, "firewall/d_nat/add_rule", natData, &result); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to create NAT rule", err, natDestinationFields)
 		return
 	}
 
 	if uuid, ok := result["uuid"].(string); ok {
 		data.ID = types.StringValue(uuid)
 	} else {
-		resp.Diagnostics.AddError("API Error", fmt.Sprintf("No UUID returned from API: %s", string(body)))
+		resp.Diagnostics.AddError("API Error", fmt.Sprintf("No UUID returned from API: %v", result))
 		return
 	}
 
+	prior := NatDestinationResourceModel{FilterRule: types.StringNull(), FilterRuleID: types.StringNull()}
+	r.syncFilterRule(ctx, &data, prior, &resp.Diagnostics)
+
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/firewall/apply", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "firewall/apply", &resp.Diagnostics)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -215,89 +261,253 @@ func (r *NatDestinationResource) Read(ctx context.Context, req resource.ReadRequ
 		return
 	}
 
-	url := fmt.Sprintf("%s/api/firewall/d_nat/get_rule/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
+	rule, err := r.client.GetItem(ctx, "firewall/d_nat/get_rule/"+data.ID.ValueString(), "rule")
 	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read NAT rule: %s", err))
+		addClientError(&resp.Diagnostics, "Unable to read NAT rule", err, natDestinationFields)
 		return
 	}
-	defer httpResp.Body.Close()
-
-	if httpResp.StatusCode == 404 {
+	if rule == nil {
 		resp.State.RemoveResource(ctx)
 		return
 	}
 
+	refreshNatDestination(&data, parseNatDestination(rule))
+
+	// An associated filter rule deleted in the GUI is planned for creation
+	// again; unassociated rules are not tracked after creation.
+	if natFilterRuleMode(data.FilterRule) == natFilterRuleAssociated && !data.FilterRuleID.IsNull() {
+		filterRule, err := r.client.GetItem(ctx, "firewall/filter/getRule/"+data.FilterRuleID.ValueString(), "rule")
+		if err != nil {
+			addClientError(&resp.Diagnostics, "Unable to read associated filter rule", err, nil)
+			return
+		}
+		if filterRule == nil {
+			data.FilterRuleID = types.StringNull()
+		}
+	}
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
-func (r *NatDestinationResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	var data NatDestinationResourceModel
-	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
-	if resp.Diagnostics.HasError() {
-		return
+// natDestinationPayload builds the rule object for add_rule and set_rule.
+// Unset optional attributes are sent with their defaults so removing one
+// from the configuration clears it in OPNsense as well.
+func natDestinationPayload(data *NatDestinationResourceModel) map[string]interface{} {
+	rule := map[string]interface{}{
+		"enabled":     "1",
+		"interface":   data.Interface.ValueString(),
+		"protocol":    data.Protocol.ValueString(),
+		"source":      "any",
+		"src_port":    data.SourcePort.ValueString(),
+		"destination": "any",
+		"dst_port":    data.DestinationPort.ValueString(),
+		"target":      data.TargetIP.ValueString(),
+		"local_port":  data.TargetPort.ValueString(),
+		"description": data.Description.ValueString(),
+		"log":         "0",
 	}
+	if !data.Enabled.IsNull() && !data.Enabled.ValueBool() {
+		rule["enabled"] = "0"
+	}
+	if !data.SourceNet.IsNull() {
+		rule["source"] = data.SourceNet.ValueString()
+	}
+	if !data.DestinationNet.IsNull() {
+		rule["destination"] = data.DestinationNet.ValueString()
+	}
+	if !data.Log.IsNull() && data.Log.ValueBool() {
+		rule["log"] = "1"
+	}
+	if natFilterRuleMode(data.FilterRule) == natFilterRulePass {
+		rule["pass"] = "1"
+	} else {
+		rule["pass"] = "0"
+	}
+	return map[string]interface{}{"rule": rule}
+}
 
-	natData := map[string]interface{}{
+// natFilterRulePayload builds the pass rule for the traffic a port forward
+// lets in, matching the rule the GUI creates: after translation the packets
+// are addressed to the target, so the rule matches target_ip and
+// target_port.
+func natFilterRulePayload(data *NatDestinationResourceModel) map[string]interface{} {
+	ipprotocol := "inet"
+	if strings.Contains(data.TargetIP.ValueString(), ":") {
+		ipprotocol = "inet6"
+	}
+	enabled, log := "1", "0"
+	if !data.Enabled.IsNull() && !data.Enabled.ValueBool() {
+		enabled = "0"
+	}
+	if !data.Log.IsNull() && data.Log.ValueBool() {
+		log = "1"
+	}
+	source := "any"
+	if !data.SourceNet.IsNull() {
+		source = data.SourceNet.ValueString()
+	}
+
+	return map[string]interface{}{
 		"rule": map[string]interface{}{
-			"interface":   data.Interface.ValueString(),
-			"protocol":    data.Protocol.ValueString(),
-			"dst_port":    data.DestinationPort.ValueString(),
-			"target":      data.TargetIP.ValueString(),
-			"local_port":  data.TargetPort.ValueString(),
+			"enabled":          enabled,
+			"description":      strings.TrimSpace("NAT " + data.Description.ValueString()),
+			"interface":        data.Interface.ValueString(),
+			"direction":        "in",
+			"ipprotocol":       ipprotocol,
+			"protocol":         strings.ToUpper(data.Protocol.ValueString()),
+			"source_net":       source,
+			"source_port":      data.SourcePort.ValueString(),
+			"destination_net":  data.TargetIP.ValueString(),
+			"destination_port": data.TargetPort.ValueString(),
+			"action":           "pass",
+			"quick":            "1",
+			"log":              log,
 		},
 	}
+}
 
-	if !data.Enabled.IsNull() {
-		if data.Enabled.ValueBool() {
-			natData["rule"].(map[string]interface{})["enabled"] = "1"
-		} else {
-			natData["rule"].(map[string]interface{})["enabled"] = "0"
+// syncFilterRule creates, updates or deletes the filter rule of data after
+// the NAT rule has been saved, going from the filter rule of prior. An
+// associated rule follows every change of the NAT rule; an unassociated one
+// is only written when it is created.
+func (r *NatDestinationResource) syncFilterRule(ctx context.Context, data *NatDestinationResourceModel, prior NatDestinationResourceModel, diags *diag.Diagnostics) {
+	mode := natFilterRuleMode(data.FilterRule)
+	changed := false
+
+	switch {
+	case mode == natFilterRuleAssociated && keepsFilterRule(prior):
+		data.FilterRuleID = prior.FilterRuleID
+		err := r.client.Post(ctx, "firewall/filter/setRule/"+prior.FilterRuleID.ValueString(), natFilterRulePayload(data), nil)
+		if err != nil {
+			addClientError(diags, "Unable to update associated filter rule", err, nil)
+			return
+		}
+		changed = true
+	case mode == natFilterRuleUnassociated && keepsFilterRule(prior):
+		data.FilterRuleID = prior.FilterRuleID
+	case mode == natFilterRuleAssociated || mode == natFilterRuleUnassociated:
+		data.FilterRuleID = types.StringNull()
+		var result map[string]interface{}
+		if err := r.client.Post(ctx, "firewall/filter/addRule", natFilterRulePayload(data), &result); err != nil {
+			addClientError(diags, "Unable to create filter rule for NAT rule", err, nil)
+			return
+		}
+		uuid, ok := result["uuid"].(string)
+		if !ok {
+			diags.AddError("API Error", fmt.Sprintf("No UUID returned from API: %v", result))
+			return
+		}
+		data.FilterRuleID = types.StringValue(uuid)
+		changed = true
+	default:
+		data.FilterRuleID = types.StringNull()
+		// Only associated rules belong to the NAT rule.
+		if natFilterRuleMode(prior.FilterRule) == natFilterRuleAssociated && !prior.FilterRuleID.IsNull() {
+			changed = r.deleteFilterRule(ctx, prior.FilterRuleID.ValueString(), diags)
 		}
 	}
 
-	if !data.SourceNet.IsNull() {
-		natData["rule"].(map[string]interface{})["source"] = data.SourceNet.ValueString()
+	if changed {
+		r.client.applyChanges(ctx, "firewall/filter/apply", diags)
 	}
+}
 
-	if !data.SourcePort.IsNull() {
-		natData["rule"].(map[string]interface{})["src_port"] = data.SourcePort.ValueString()
+// deleteFilterRule deletes an associated filter rule, tolerating one that
+// is already gone. It reports whether a rule was deleted.
+func (r *NatDestinationResource) deleteFilterRule(ctx context.Context, uuid string, diags *diag.Diagnostics) bool {
+	err := r.client.Post(ctx, "firewall/filter/delRule/"+uuid, nil, nil)
+	if IsNotFound(err) {
+		return false
+	}
+	if err != nil {
+		addClientError(diags, "Unable to delete associated filter rule", err, nil)
+		return false
 	}
+	return true
+}
 
-	if !data.DestinationNet.IsNull() {
-		natData["rule"].(map[string]interface{})["destination"] = data.DestinationNet.ValueString()
+// natDestinationValues is the decoded form of the rule object returned by
+// firewall/d_nat/get_rule.
+type natDestinationValues struct {
+	Enabled         bool
+	Interface       string
+	Protocol        string
+	SourceNet       string
+	SourcePort      string
+	DestinationNet  string
+	DestinationPort string
+	TargetIP        string
+	TargetPort      string
+	Description     string
+	Log             bool
+	Pass            bool
+}
+
+func parseNatDestination(rule map[string]interface{}) natDestinationValues {
+	return natDestinationValues{
+		Enabled:         boolValue(rule["enabled"]),
+		Interface:       selectedOption(rule["interface"]),
+		Protocol:        stringValue(rule["protocol"]),
+		SourceNet:       stringValue(rule["source"]),
+		SourcePort:      stringValue(rule["src_port"]),
+		DestinationNet:  stringValue(rule["destination"]),
+		DestinationPort: stringValue(rule["dst_port"]),
+		TargetIP:        stringValue(rule["target"]),
+		TargetPort:      stringValue(rule["local_port"]),
+		Description:     stringValue(rule["description"]),
+		Log:             boolValue(rule["log"]),
+		Pass:            boolValue(rule["pass"]),
 	}
+}
 
-	if !data.Description.IsNull() {
-		natData["rule"].(map[string]interface{})["description"] = data.Description.ValueString()
+// refreshNatDestination copies the values OPNsense reports into data.
+// Optional attributes that were never configured stay null while OPNsense
+// reports their default, so neither import nor refresh invents a diff.
+func refreshNatDestination(data *NatDestinationResourceModel, rule natDestinationValues) {
+	data.Enabled = refreshBool(data.Enabled, rule.Enabled, true)
+	data.Interface = refreshRequiredString(data.Interface, rule.Interface)
+	data.Protocol = refreshRequiredString(data.Protocol, rule.Protocol)
+	data.SourceNet = refreshString(data.SourceNet, rule.SourceNet, "any")
+	data.SourcePort = refreshString(data.SourcePort, rule.SourcePort, "")
+	data.DestinationNet = refreshString(data.DestinationNet, rule.DestinationNet, "any")
+	data.DestinationPort = refreshRequiredString(data.DestinationPort, rule.DestinationPort)
+	data.TargetIP = refreshRequiredString(data.TargetIP, rule.TargetIP)
+	data.TargetPort = refreshRequiredString(data.TargetPort, rule.TargetPort)
+	data.Description = refreshString(data.Description, rule.Description, "")
+	data.Log = refreshBool(data.Log, rule.Log, false)
+
+	switch {
+	case rule.Pass:
+		data.FilterRule = types.StringValue(natFilterRulePass)
+	case natFilterRuleMode(data.FilterRule) == natFilterRulePass:
+		data.FilterRule = types.StringNull()
 	}
+}
 
-	if !data.Log.IsNull() && data.Log.ValueBool() {
-		natData["rule"].(map[string]interface{})["log"] = "1"
+func (r *NatDestinationResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data NatDestinationResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	jsonData, _ := json.Marshal(natData)
+	var prior NatDestinationResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	url := fmt.Sprintf("%s/api/firewall/d_nat/set_rule/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
+	natData := natDestinationPayload(&data)
 
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update NAT rule: %s", err))
+	if err := r.client.Post(ctx, "firewall/d_nat/set_rule/"+data.ID.ValueString(), natData, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to update NAT rule", err, natDestinationFields)
 		return
 	}
-	defer httpResp.Body.Close()
+
+	r.syncFilterRule(ctx, &data, prior, &resp.Diagnostics)
 
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/firewall/apply", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "firewall/apply", &resp.Diagnostics)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -309,24 +519,31 @@ func (r *NatDestinationResource) Delete(ctx context.Context, req resource.Delete
 		return
 	}
 
-	url := fmt.Sprintf("%s/api/firewall/d_nat/del_rule/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, nil)
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete NAT rule: %s", err))
+	if err := r.client.Post(ctx, "firewall/d_nat/del_rule/"+data.ID.ValueString(), nil, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to delete NAT rule", err, natDestinationFields)
 		return
 	}
-	defer httpResp.Body.Close()
+
+	if natFilterRuleMode(data.FilterRule) == natFilterRuleAssociated && !data.FilterRuleID.IsNull() {
+		if r.deleteFilterRule(ctx, data.FilterRuleID.ValueString(), &resp.Diagnostics) {
+			r.client.applyChanges(ctx, "firewall/filter/apply", &resp.Diagnostics)
+		}
+	}
 
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/firewall/apply", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "firewall/apply", &resp.Diagnostics)
 }
 
+// ImportState accepts either the rule UUID or its exact description.
 func (r *NatDestinationResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	id := req.ID
+	if !isUUID(id) {
+		uuid, err := r.client.FindByDescription(ctx, "firewall/d_nat/search_rule", "firewall/d_nat/get_rule/", "rule", id, "NAT rule")
+		if err != nil {
+			addClientError(&resp.Diagnostics, "Unable to import NAT rule", err, nil)
+			return
+		}
+		id = uuid
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
 }
@@ -0,0 +1,296 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestNatDestinationResource(t *testing.T) {
+	f := newFakeOPNsense(t)
+	var id string
+
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: `
+resource "opnsense_nat_destination" "test" {
+  interface        = "wan"
+  protocol         = "tcp"
+  destination_port = "443"
+  target_ip        = "10.0.0.10"
+  target_port      = "8443"
+  description      = "HTTPS to web"
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				captureID("opnsense_nat_destination.test", &id),
+				checkFakeField(f, "dnat.rule", "opnsense_nat_destination.test", "target", "10.0.0.10"),
+				checkFakeField(f, "dnat.rule", "opnsense_nat_destination.test", "local_port", "8443"),
+				checkApplied(f, "firewall/apply"),
+			),
+		},
+		resource.TestStep{
+			Config: `
+resource "opnsense_nat_destination" "test" {
+  interface        = "wan"
+  protocol         = "tcp"
+  destination_port = "443"
+  target_ip        = "10.0.0.11"
+  target_port      = "443"
+  description      = "HTTPS to web"
+  log              = true
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkFakeField(f, "dnat.rule", "opnsense_nat_destination.test", "target", "10.0.0.11"),
+				checkFakeField(f, "dnat.rule", "opnsense_nat_destination.test", "log", "1"),
+			),
+		},
+		resource.TestStep{
+			ResourceName:      "opnsense_nat_destination.test",
+			ImportState:       true,
+			ImportStateVerify: true,
+		},
+		resource.TestStep{
+			ResourceName:      "opnsense_nat_destination.test",
+			ImportState:       true,
+			ImportStateId:     "HTTPS to web",
+			ImportStateVerify: true,
+		},
+		resource.TestStep{
+			// A port forward retargeted in the GUI is planned back.
+			PreConfig: func() { f.Update("dnat.rule", id, map[string]interface{}{"target": "10.0.0.99"}) },
+			Config: `
+resource "opnsense_nat_destination" "test" {
+  interface        = "wan"
+  protocol         = "tcp"
+  destination_port = "443"
+  target_ip        = "10.0.0.11"
+  target_port      = "443"
+  description      = "HTTPS to web"
+  log              = true
+}
+`,
+			Check: checkFakeField(f, "dnat.rule", "opnsense_nat_destination.test", "target", "10.0.0.11"),
+		},
+		resource.TestStep{
+			// Removing optional attributes clears them in OPNsense.
+			Config: `
+resource "opnsense_nat_destination" "test" {
+  interface        = "wan"
+  protocol         = "tcp"
+  destination_port = "443"
+  target_ip        = "10.0.0.11"
+  target_port      = "443"
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkFakeField(f, "dnat.rule", "opnsense_nat_destination.test", "description", ""),
+				checkFakeField(f, "dnat.rule", "opnsense_nat_destination.test", "log", "0"),
+			),
+		},
+	)
+
+	if items := f.Items("dnat.rule"); len(items) != 0 {
+		t.Errorf("%d NAT rule(s) left after destroy", len(items))
+	}
+}
+
+func TestNatDestinationResourceImportByDescription(t *testing.T) {
+	f := newFakeOPNsense(t)
+	f.Put("dnat.rule", map[string]interface{}{
+		"interface": "wan", "protocol": "udp", "dst_port": "51820",
+		"target": "10.0.0.20", "local_port": "51820", "description": "WireGuard",
+	})
+	f.Put("dnat.rule", map[string]interface{}{
+		"interface": "wan", "protocol": "tcp", "dst_port": "80",
+		"target": "10.0.0.10", "local_port": "80", "description": "WireGuard setup page",
+	})
+	f.Put("dnat.rule", map[string]interface{}{
+		"interface": "wan", "protocol": "tcp", "dst_port": "22",
+		"target": "10.0.0.30", "local_port": "22", "description": "SSH",
+	})
+	f.Put("dnat.rule", map[string]interface{}{
+		"interface": "wan", "protocol": "tcp", "dst_port": "2222",
+		"target": "10.0.0.31", "local_port": "22", "description": "SSH",
+	})
+
+	config := `
+resource "opnsense_nat_destination" "test" {
+  interface        = "wan"
+  protocol         = "udp"
+  destination_port = "51820"
+  target_ip        = "10.0.0.20"
+  target_port      = "51820"
+  description      = "WireGuard"
+}
+`
+	withOther := config + `
+resource "opnsense_nat_destination" "other" {
+  interface        = "wan"
+  protocol         = "tcp"
+  destination_port = "22"
+  target_ip        = "10.0.0.30"
+  target_port      = "22"
+}
+`
+	fakeTest(t, f,
+		resource.TestStep{
+			// Only the exact description matches, and the imported state
+			// needs no changes.
+			Config:             config,
+			ResourceName:       "opnsense_nat_destination.test",
+			ImportState:        true,
+			ImportStateId:      "WireGuard",
+			ImportStatePersist: true,
+			ImportStateCheck: func(states []*terraform.InstanceState) error {
+				if got := states[0].Attributes["target_ip"]; got != "10.0.0.20" {
+					return fmt.Errorf("imported target_ip = %q, want 10.0.0.20", got)
+				}
+				return nil
+			},
+		},
+		resource.TestStep{
+			Config:   config,
+			PlanOnly: true,
+		},
+		resource.TestStep{
+			Config:        withOther,
+			ResourceName:  "opnsense_nat_destination.other",
+			ImportState:   true,
+			ImportStateId: "SSH",
+			ExpectError:   regexp.MustCompile(`2 NAT rules have the description "SSH"`),
+		},
+		resource.TestStep{
+			Config:        withOther,
+			ResourceName:  "opnsense_nat_destination.other",
+			ImportState:   true,
+			ImportStateId: "FTP",
+			ExpectError:   regexp.MustCompile(`no NAT rule has the UUID or description "FTP"`),
+		},
+	)
+}
+
+// natWithAssociation is a port forward to target using filter_rule_association.
+func natWithAssociation(association, target string) string {
+	return fmt.Sprintf(`
+resource "opnsense_nat_destination" "test" {
+  interface               = "wan"
+  protocol                = "tcp"
+  destination_port        = "443"
+  target_ip               = %q
+  target_port             = "8443"
+  description             = "Traefik"
+  filter_rule_association = %q
+}
+`, target, association)
+}
+
+// checkNatFilterRule asserts a field of the filter rule linked to the port
+// forward.
+func checkNatFilterRule(f *fakeOPNsense, id *string, field, want string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources["opnsense_nat_destination.test"]
+		if !ok {
+			return fmt.Errorf("opnsense_nat_destination.test not found in state")
+		}
+		*id = rs.Primary.Attributes["filter_rule_id"]
+		rule := f.Item("filter.rule", *id)
+		if rule == nil {
+			return fmt.Errorf("filter rule %q not found in OPNsense", *id)
+		}
+		if got := fakeString(rule[field]); got != want {
+			return fmt.Errorf("filter rule %s = %q, want %q", field, got, want)
+		}
+		return nil
+	}
+}
+
+func TestNatDestinationResourceFilterRuleAssociation(t *testing.T) {
+	f := newFakeOPNsense(t)
+	var ruleID, replacedID string
+
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: natWithAssociation("associated", "10.0.0.10"),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkNatFilterRule(f, &ruleID, "destination_net", "10.0.0.10"),
+				checkNatFilterRule(f, &ruleID, "destination_port", "8443"),
+				checkNatFilterRule(f, &ruleID, "action", "pass"),
+				checkNatFilterRule(f, &ruleID, "description", "NAT Traefik"),
+				checkApplied(f, "firewall/filter/apply"),
+			),
+		},
+		resource.TestStep{
+			// The associated rule follows the port forward.
+			Config: natWithAssociation("associated", "10.0.0.11"),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttrPtr("opnsense_nat_destination.test", "filter_rule_id", &ruleID),
+				checkNatFilterRule(f, &ruleID, "destination_net", "10.0.0.11"),
+			),
+		},
+		resource.TestStep{
+			// An associated rule deleted in the GUI is created again.
+			PreConfig: func() { f.Remove("filter.rule", ruleID) },
+			Config:    natWithAssociation("associated", "10.0.0.11"),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkNatFilterRule(f, &replacedID, "destination_net", "10.0.0.11"),
+				func(*terraform.State) error {
+					if replacedID == ruleID {
+						return fmt.Errorf("filter rule %s was not replaced", ruleID)
+					}
+					return nil
+				},
+			),
+		},
+		resource.TestStep{
+			Config: natWithAssociation("pass", "10.0.0.11"),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckNoResourceAttr("opnsense_nat_destination.test", "filter_rule_id"),
+				checkFakeField(f, "dnat.rule", "opnsense_nat_destination.test", "pass", "1"),
+				checkFakeEmpty(f, "filter.rule"),
+			),
+		},
+		resource.TestStep{
+			Config: natWithAssociation("unassociated", "10.0.0.11"),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkFakeField(f, "dnat.rule", "opnsense_nat_destination.test", "pass", "0"),
+				checkNatFilterRule(f, &ruleID, "destination_net", "10.0.0.11"),
+			),
+		},
+		resource.TestStep{
+			// An unassociated rule is left as it was created.
+			Config: natWithAssociation("unassociated", "10.0.0.12"),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkFakeField(f, "dnat.rule", "opnsense_nat_destination.test", "target", "10.0.0.12"),
+				checkNatFilterRule(f, &ruleID, "destination_net", "10.0.0.11"),
+			),
+		},
+		resource.TestStep{
+			Config:      natWithAssociation("always", "10.0.0.12"),
+			ExpectError: regexp.MustCompile(`filter_rule_association\s+must\s+be`),
+		},
+	)
+
+	// Unassociated rules outlive the port forward, like in the GUI.
+	if rules := f.Items("filter.rule"); len(rules) != 1 {
+		t.Errorf("%d filter rule(s) left after destroy, want the unassociated one", len(rules))
+	}
+}
+
+func TestNatDestinationResourceDeletesAssociatedRule(t *testing.T) {
+	f := newFakeOPNsense(t)
+	var ruleID string
+
+	fakeTest(t, f, resource.TestStep{
+		Config: natWithAssociation("associated", "10.0.0.10"),
+		Check:  checkNatFilterRule(f, &ruleID, "interface", "wan"),
+	})
+
+	if rules := f.Items("filter.rule"); len(rules) != 0 {
+		t.Errorf("%d filter rule(s) left after destroy, want the associated rule deleted", len(rules))
+	}
+}
@@ -0,0 +1,395 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ resource.Resource = &NatSourceResource{}
+var _ resource.ResourceWithImportState = &NatSourceResource{}
+var _ resource.ResourceWithModifyPlan = &NatSourceResource{}
+
+func NewNatSourceResource() resource.Resource {
+	return &NatSourceResource{}
+}
+
+type NatSourceResource struct {
+	client *Client
+}
+
+type NatSourceResourceModel struct {
+	ID              types.String `tfsdk:"id"`
+	Enabled         types.Bool   `tfsdk:"enabled"`
+	NoNat           types.Bool   `tfsdk:"no_nat"`
+	Sequence        types.Int64  `tfsdk:"sequence"`
+	Interface       types.String `tfsdk:"interface"`
+	IPProtocol      types.String `tfsdk:"ip_protocol"`
+	Protocol        types.String `tfsdk:"protocol"`
+	SourceNet       types.String `tfsdk:"source_net"`
+	SourcePort      types.String `tfsdk:"source_port"`
+	DestinationNet  types.String `tfsdk:"destination_net"`
+	DestinationPort types.String `tfsdk:"destination_port"`
+	Target          types.String `tfsdk:"target"`
+	TargetPort      types.String `tfsdk:"target_port"`
+	StaticPort      types.Bool   `tfsdk:"static_port"`
+	PoolOptions     types.String `tfsdk:"pool_options"`
+	Description     types.String `tfsdk:"description"`
+	Log             types.Bool   `tfsdk:"log"`
+}
+
+// natSourceFields maps OPNsense outbound NAT fields to resource attributes.
+var natSourceFields = map[string]string{
+	"enabled":          "enabled",
+	"nonat":            "no_nat",
+	"sequence":         "sequence",
+	"interface":        "interface",
+	"ipprotocol":       "ip_protocol",
+	"protocol":         "protocol",
+	"source_net":       "source_net",
+	"source_port":      "source_port",
+	"destination_net":  "destination_net",
+	"destination_port": "destination_port",
+	"target":           "target",
+	"target_port":      "target_port",
+	"staticnatport":    "static_port",
+	"poolopts":         "pool_options",
+	"description":      "description",
+	"log":              "log",
+}
+
+const natSourceApplyEndpoint = "firewall/source_nat/apply"
+
+func (r *NatSourceResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_nat_source"
+}
+
+func (r *NatSourceResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manages outbound (source) NAT rules in OPNsense 26.1. Rules are only used when " +
+			"the outbound NAT mode is set to hybrid or manual under Firewall > NAT > Outbound",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "NAT rule UUID",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"enabled": schema.BoolAttribute{
+				MarkdownDescription: "Enable this NAT rule",
+				Optional:            true,
+			},
+			"no_nat": schema.BoolAttribute{
+				MarkdownDescription: "Do not translate matching traffic, e.g. to exempt VPN subnets from a broader rule",
+				Optional:            true,
+			},
+			"sequence": schema.Int64Attribute{
+				MarkdownDescription: "Rule sequence/sort order. Lower numbers are processed first",
+				Optional:            true,
+				Computed:            true,
+			},
+			"interface": schema.StringAttribute{
+				MarkdownDescription: "Interface the traffic leaves on (e.g., 'wan')",
+				Required:            true,
+			},
+			"ip_protocol": schema.StringAttribute{
+				MarkdownDescription: "IP version ('inet' or 'inet6'). Default is 'inet'",
+				Optional:            true,
+			},
+			"protocol": schema.StringAttribute{
+				MarkdownDescription: "Protocol (any, tcp, udp, ...). Default is 'any'",
+				Optional:            true,
+			},
+			"source_net": schema.StringAttribute{
+				MarkdownDescription: "Source network, address or alias (e.g., '10.0.20.0/24')",
+				Required:            true,
+			},
+			"source_port": schema.StringAttribute{
+				MarkdownDescription: "Source port",
+				Optional:            true,
+			},
+			"destination_net": schema.StringAttribute{
+				MarkdownDescription: "Destination network (default: 'any')",
+				Optional:            true,
+			},
+			"destination_port": schema.StringAttribute{
+				MarkdownDescription: "Destination port",
+				Optional:            true,
+			},
+			"target": schema.StringAttribute{
+				MarkdownDescription: "Translation target address, network or alias. Default is the interface address",
+				Optional:            true,
+			},
+			"target_port": schema.StringAttribute{
+				MarkdownDescription: "Translation port",
+				Optional:            true,
+			},
+			"static_port": schema.BoolAttribute{
+				MarkdownDescription: "Keep the source port instead of randomizing it",
+				Optional:            true,
+			},
+			"pool_options": schema.StringAttribute{
+				MarkdownDescription: "How addresses are picked when target is a network or alias: round-robin, " +
+					"round-robin sticky-address, random, random sticky-address, source-hash or bitmask",
+				Optional: true,
+			},
+			"description": schema.StringAttribute{
+				MarkdownDescription: "Description",
+				Optional:            true,
+			},
+			"log": schema.BoolAttribute{
+				MarkdownDescription: "Log packets matching this rule",
+				Optional:            true,
+			},
+		},
+	}
+}
+
+func (r *NatSourceResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *Client, got: %T.", req.ProviderData),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+// ModifyPlan fails the plan when the firewall does not provide source NAT.
+func (r *NatSourceResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	r.client.requireSubsystem(subsystemSNat, &resp.Diagnostics)
+}
+
+func (r *NatSourceResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data NatSourceResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var result map[string]interface{}
+	if err := r.client.Post(ctx, "firewall/source_nat/add_rule", natSourcePayload(&data), &result); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to create outbound NAT rule", err, natSourceFields)
+		return
+	}
+
+	if uuid, ok := result["uuid"].(string); ok {
+		data.ID = types.StringValue(uuid)
+	} else {
+		resp.Diagnostics.AddError("API Error", fmt.Sprintf("No UUID returned from API: %v", result))
+		return
+	}
+
+	resolveSequence(ctx, r.client, "firewall/source_nat/get_rule/"+data.ID.ValueString(), "rule", &data.Sequence, &resp.Diagnostics)
+
+	// Apply configuration
+	r.client.applyChanges(ctx, natSourceApplyEndpoint, &resp.Diagnostics)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *NatSourceResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data NatSourceResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	rule, err := r.client.GetItem(ctx, "firewall/source_nat/get_rule/"+data.ID.ValueString(), "rule")
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Unable to read outbound NAT rule", err, natSourceFields)
+		return
+	}
+	if rule == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	refreshNatSource(&data, parseNatSource(rule))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *NatSourceResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data NatSourceResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.Post(ctx, "firewall/source_nat/set_rule/"+data.ID.ValueString(), natSourcePayload(&data), nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to update outbound NAT rule", err, natSourceFields)
+		return
+	}
+
+	resolveSequence(ctx, r.client, "firewall/source_nat/get_rule/"+data.ID.ValueString(), "rule", &data.Sequence, &resp.Diagnostics)
+
+	// Apply configuration
+	r.client.applyChanges(ctx, natSourceApplyEndpoint, &resp.Diagnostics)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *NatSourceResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data NatSourceResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.Post(ctx, "firewall/source_nat/del_rule/"+data.ID.ValueString(), nil, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to delete outbound NAT rule", err, natSourceFields)
+		return
+	}
+
+	// Apply configuration
+	r.client.applyChanges(ctx, natSourceApplyEndpoint, &resp.Diagnostics)
+}
+
+// ImportState accepts either the rule UUID or its exact description.
+func (r *NatSourceResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	id := req.ID
+	if !isUUID(id) {
+		uuid, err := r.client.FindByDescription(ctx, "firewall/source_nat/search_rule", "firewall/source_nat/get_rule/", "rule", id, "outbound NAT rule")
+		if err != nil {
+			addClientError(&resp.Diagnostics, "Unable to import outbound NAT rule", err, nil)
+			return
+		}
+		id = uuid
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
+}
+
+// natSourcePayload builds the rule object for add_rule and set_rule. Unset
+// optional attributes are sent with their defaults so removing one from the
+// configuration clears it in OPNsense as well.
+func natSourcePayload(data *NatSourceResourceModel) map[string]interface{} {
+	rule := map[string]interface{}{
+		"enabled":          "1",
+		"nonat":            "0",
+		"interface":        data.Interface.ValueString(),
+		"ipprotocol":       "inet",
+		"protocol":         "any",
+		"source_net":       data.SourceNet.ValueString(),
+		"source_port":      data.SourcePort.ValueString(),
+		"destination_net":  "any",
+		"destination_port": data.DestinationPort.ValueString(),
+		"target":           data.Target.ValueString(),
+		"target_port":      data.TargetPort.ValueString(),
+		"staticnatport":    "0",
+		"poolopts":         data.PoolOptions.ValueString(),
+		"description":      data.Description.ValueString(),
+		"log":              "0",
+	}
+	if !data.Enabled.IsNull() && !data.Enabled.ValueBool() {
+		rule["enabled"] = "0"
+	}
+	if !data.NoNat.IsNull() && data.NoNat.ValueBool() {
+		rule["nonat"] = "1"
+	}
+	if !data.Sequence.IsNull() && !data.Sequence.IsUnknown() {
+		rule["sequence"] = fmt.Sprintf("%d", data.Sequence.ValueInt64())
+	}
+	if !data.IPProtocol.IsNull() {
+		rule["ipprotocol"] = data.IPProtocol.ValueString()
+	}
+	if !data.Protocol.IsNull() {
+		rule["protocol"] = data.Protocol.ValueString()
+	}
+	if !data.DestinationNet.IsNull() {
+		rule["destination_net"] = data.DestinationNet.ValueString()
+	}
+	if !data.StaticPort.IsNull() && data.StaticPort.ValueBool() {
+		rule["staticnatport"] = "1"
+	}
+	if !data.Log.IsNull() && data.Log.ValueBool() {
+		rule["log"] = "1"
+	}
+	return map[string]interface{}{"rule": rule}
+}
+
+// natSourceValues is the decoded form of the rule object returned by
+// firewall/source_nat/get_rule.
+type natSourceValues struct {
+	Enabled         bool
+	NoNat           bool
+	Sequence        int64
+	HasSequence     bool
+	Interface       string
+	IPProtocol      string
+	Protocol        string
+	SourceNet       string
+	SourcePort      string
+	DestinationNet  string
+	DestinationPort string
+	Target          string
+	TargetPort      string
+	StaticPort      bool
+	PoolOptions     string
+	Description     string
+	Log             bool
+}
+
+func parseNatSource(rule map[string]interface{}) natSourceValues {
+	v := natSourceValues{
+		Enabled:         boolValue(rule["enabled"]),
+		NoNat:           boolValue(rule["nonat"]),
+		Interface:       selectedOption(rule["interface"]),
+		IPProtocol:      stringValue(rule["ipprotocol"]),
+		Protocol:        stringValue(rule["protocol"]),
+		SourceNet:       stringValue(rule["source_net"]),
+		SourcePort:      stringValue(rule["source_port"]),
+		DestinationNet:  stringValue(rule["destination_net"]),
+		DestinationPort: stringValue(rule["destination_port"]),
+		Target:          stringValue(rule["target"]),
+		TargetPort:      stringValue(rule["target_port"]),
+		StaticPort:      boolValue(rule["staticnatport"]),
+		PoolOptions:     stringValue(rule["poolopts"]),
+		Description:     stringValue(rule["description"]),
+		Log:             boolValue(rule["log"]),
+	}
+	v.Sequence, v.HasSequence = int64Value(rule["sequence"])
+	return v
+}
+
+// refreshNatSource copies the values OPNsense reports into data. Optional
+// attributes that were never configured stay null while OPNsense reports
+// their default, so neither import nor refresh invents a diff.
+func refreshNatSource(data *NatSourceResourceModel, rule natSourceValues) {
+	data.Enabled = refreshBool(data.Enabled, rule.Enabled, true)
+	data.NoNat = refreshBool(data.NoNat, rule.NoNat, false)
+	if rule.HasSequence {
+		data.Sequence = types.Int64Value(rule.Sequence)
+	}
+	data.Interface = refreshRequiredString(data.Interface, rule.Interface)
+	data.IPProtocol = refreshString(data.IPProtocol, rule.IPProtocol, "inet")
+	data.Protocol = refreshString(data.Protocol, rule.Protocol, "any")
+	data.SourceNet = refreshRequiredString(data.SourceNet, rule.SourceNet)
+	data.SourcePort = refreshString(data.SourcePort, rule.SourcePort, "")
+	data.DestinationNet = refreshString(data.DestinationNet, rule.DestinationNet, "any")
+	data.DestinationPort = refreshString(data.DestinationPort, rule.DestinationPort, "")
+	data.Target = refreshString(data.Target, rule.Target, "")
+	data.TargetPort = refreshString(data.TargetPort, rule.TargetPort, "")
+	data.StaticPort = refreshBool(data.StaticPort, rule.StaticPort, false)
+	data.PoolOptions = refreshString(data.PoolOptions, rule.PoolOptions, "")
+	data.Description = refreshString(data.Description, rule.Description, "")
+	data.Log = refreshBool(data.Log, rule.Log, false)
+}
@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestNatSourceResource(t *testing.T) {
+	f := newFakeOPNsense(t)
+	var id string
+
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: `
+resource "opnsense_nat_source" "test" {
+  interface   = "wan"
+  source_net  = "10.0.20.0/24"
+  target      = "203.0.113.10"
+  static_port = true
+  description = "IoT VLAN"
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				captureID("opnsense_nat_source.test", &id),
+				resource.TestCheckResourceAttrSet("opnsense_nat_source.test", "sequence"),
+				checkFakeField(f, "snat.rule", "opnsense_nat_source.test", "source_net", "10.0.20.0/24"),
+				checkFakeField(f, "snat.rule", "opnsense_nat_source.test", "target", "203.0.113.10"),
+				checkFakeField(f, "snat.rule", "opnsense_nat_source.test", "staticnatport", "1"),
+				checkApplied(f, "firewall/source_nat/apply"),
+			),
+		},
+		resource.TestStep{
+			Config: `
+resource "opnsense_nat_source" "test" {
+  interface        = "wan"
+  source_net       = "10.0.20.0/24"
+  destination_net  = "10.8.0.0/24"
+  protocol         = "udp"
+  destination_port = "53"
+  no_nat           = true
+  sequence         = 10
+  pool_options     = "round-robin"
+  description      = "IoT VLAN"
+  log              = true
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkFakeField(f, "snat.rule", "opnsense_nat_source.test", "nonat", "1"),
+				checkFakeField(f, "snat.rule", "opnsense_nat_source.test", "sequence", "10"),
+				checkFakeField(f, "snat.rule", "opnsense_nat_source.test", "destination_net", "10.8.0.0/24"),
+				checkFakeField(f, "snat.rule", "opnsense_nat_source.test", "poolopts", "round-robin"),
+				// Removed attributes are cleared.
+				checkFakeField(f, "snat.rule", "opnsense_nat_source.test", "target", ""),
+				checkFakeField(f, "snat.rule", "opnsense_nat_source.test", "staticnatport", "0"),
+			),
+		},
+		resource.TestStep{
+			ResourceName:      "opnsense_nat_source.test",
+			ImportState:       true,
+			ImportStateVerify: true,
+		},
+		resource.TestStep{
+			ResourceName:      "opnsense_nat_source.test",
+			ImportState:       true,
+			ImportStateId:     "IoT VLAN",
+			ImportStateVerify: true,
+		},
+		resource.TestStep{
+			// A rule changed in the GUI is planned back.
+			PreConfig: func() { f.Update("snat.rule", id, map[string]interface{}{"nonat": "0"}) },
+			Config: `
+resource "opnsense_nat_source" "test" {
+  interface        = "wan"
+  source_net       = "10.0.20.0/24"
+  destination_net  = "10.8.0.0/24"
+  protocol         = "udp"
+  destination_port = "53"
+  no_nat           = true
+  sequence         = 10
+  pool_options     = "round-robin"
+  description      = "IoT VLAN"
+  log              = true
+}
+`,
+			PlanOnly:           true,
+			ExpectNonEmptyPlan: true,
+		},
+		resource.TestStep{
+			Config: `
+resource "opnsense_nat_source" "test" {
+  interface  = "wan"
+  source_net = "10.0.20.0/24"
+  target     = "not an address"
+}
+`,
+			ExpectError: regexp.MustCompile(`(?s)target.*not a valid`),
+		},
+	)
+
+	if items := f.Items("snat.rule"); len(items) != 0 {
+		t.Errorf("%d outbound NAT rule(s) left after destroy", len(items))
+	}
+}
@@ -2,22 +2,24 @@ package provider
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
+	"sync"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 var _ resource.Resource = &WireguardPeerResource{}
 var _ resource.ResourceWithImportState = &WireguardPeerResource{}
+var _ resource.ResourceWithModifyPlan = &WireguardPeerResource{}
+var _ resource.ResourceWithConfigValidators = &WireguardPeerResource{}
 
 func NewWireguardPeerResource() resource.Resource {
 	return &WireguardPeerResource{}
@@ -28,15 +30,28 @@ type WireguardPeerResource struct {
 }
 
 type WireguardPeerResourceModel struct {
-	ID               types.String `tfsdk:"id"`
-	Name             types.String `tfsdk:"name"`
-	Enabled          types.Bool   `tfsdk:"enabled"`
-	PublicKey        types.String `tfsdk:"public_key"`
-	AllowedIPs       types.String `tfsdk:"allowed_ips"`
-	Endpoint         types.String `tfsdk:"endpoint"`
-	EndpointPort     types.Int64  `tfsdk:"endpoint_port"`
-	PresharedKey     types.String `tfsdk:"preshared_key"`
-	Keepalive        types.Int64  `tfsdk:"keepalive"`
+	ID           types.String `tfsdk:"id"`
+	Name         types.String `tfsdk:"name"`
+	Enabled      types.Bool   `tfsdk:"enabled"`
+	PublicKey    types.String `tfsdk:"public_key"`
+	AllowedIPs   types.String `tfsdk:"allowed_ips"`
+	Endpoint     types.String `tfsdk:"endpoint"`
+	EndpointPort types.Int64  `tfsdk:"endpoint_port"`
+	PresharedKey types.String `tfsdk:"preshared_key"`
+	Keepalive    types.Int64  `tfsdk:"keepalive"`
+	AllocateFrom types.String `tfsdk:"allocate_from_server"`
+}
+
+// wireguardPeerFields maps OPNsense client fields to resource attributes.
+var wireguardPeerFields = map[string]string{
+	"name":          "name",
+	"enabled":       "enabled",
+	"pubkey":        "public_key",
+	"tunneladdress": "allowed_ips",
+	"serveraddress": "endpoint",
+	"serverport":    "endpoint_port",
+	"psk":           "preshared_key",
+	"keepalive":     "keepalive",
 }
 
 func (r *WireguardPeerResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -68,8 +83,16 @@ func (r *WireguardPeerResource) Schema(ctx context.Context, req resource.SchemaR
 				Required:            true,
 			},
 			"allowed_ips": schema.StringAttribute{
-				MarkdownDescription: "Comma-separated list of allowed IP addresses/networks",
-				Required:            true,
+				MarkdownDescription: "Comma-separated list of allowed IP addresses/networks. Computed when `allocate_from_server` is set",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"allocate_from_server": schema.StringAttribute{
+				MarkdownDescription: "UUID of a WireGuard server. The peer gets the next free host address of the server's tunnel network as `allowed_ips`, which is kept on later applies",
+				Optional:            true,
 			},
 			"endpoint": schema.StringAttribute{
 				MarkdownDescription: "Endpoint hostname or IP address",
@@ -109,6 +132,71 @@ func (r *WireguardPeerResource) Configure(ctx context.Context, req resource.Conf
 	r.client = client
 }
 
+// ConfigValidators requires exactly one of allowed_ips and
+// allocate_from_server. Both validators skip unknown values; Terraform
+// validates again once they are known.
+func (r *WireguardPeerResource) ConfigValidators(ctx context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.Conflicting(path.MatchRoot("allowed_ips"), path.MatchRoot("allocate_from_server")),
+		resourcevalidator.AtLeastOneOf(path.MatchRoot("allowed_ips"), path.MatchRoot("allocate_from_server")),
+	}
+}
+
+// ModifyPlan fails the plan when the firewall does not provide WireGuard and
+// plans a new tunnel address when allocate_from_server changes.
+func (r *WireguardPeerResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	r.client.requireSubsystem(subsystemWireguard, &resp.Diagnostics)
+
+	var allowedIPs, allocateFrom types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("allowed_ips"), &allowedIPs)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("allocate_from_server"), &allocateFrom)...)
+	if resp.Diagnostics.HasError() || allocateFrom.IsNull() || !allowedIPs.IsNull() {
+		return
+	}
+
+	// Keep the allocated address unless the peer moves to another server
+	// or switches from a fixed address to allocation. A server that is not
+	// known yet, e.g. because it is replaced in the same apply, may be
+	// another one, so the address is planned as unknown; it is planned again
+	// from the state once the server is known.
+	if !req.State.Raw.IsNull() && !allocateFrom.IsUnknown() {
+		var prior types.String
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("allocate_from_server"), &prior)...)
+		if resp.Diagnostics.HasError() || prior.Equal(allocateFrom) {
+			return
+		}
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("allowed_ips"), types.StringUnknown())...)
+}
+
+// allocateAddress fills in allowed_ips from allocate_from_server when the
+// plan left it unknown. It returns with r.client.allocations held when it
+// allocated; the caller releases it once the peer has been saved. unlock
+// may be called more than once.
+func (r *WireguardPeerResource) allocateAddress(ctx context.Context, data *WireguardPeerResourceModel, diags *diag.Diagnostics) (unlock func()) {
+	if !data.AllowedIPs.IsUnknown() {
+		return func() {}
+	}
+
+	r.client.allocations.Lock()
+	addr, err := r.client.allocateWireguardAddress(ctx, data.AllocateFrom.ValueString(), data.ID.ValueString())
+	if err != nil {
+		r.client.allocations.Unlock()
+		addClientError(diags, "Unable to allocate tunnel address", err, nil)
+		return func() {}
+	}
+
+	tflog.Debug(ctx, "Allocated WireGuard tunnel address", map[string]any{
+		"server":  data.AllocateFrom.ValueString(),
+		"address": addr,
+	})
+	data.AllowedIPs = types.StringValue(addr)
+	return sync.OnceFunc(r.client.allocations.Unlock)
+}
+
 func (r *WireguardPeerResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var data WireguardPeerResourceModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
@@ -116,10 +204,16 @@ func (r *WireguardPeerResource) Create(ctx context.Context, req resource.CreateR
 		return
 	}
 
+	unlock := r.allocateAddress(ctx, &data, &resp.Diagnostics)
+	defer unlock()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	peerData := map[string]interface{}{
 		"client": map[string]interface{}{
-			"name":       data.Name.ValueString(),
-			"pubkey":     data.PublicKey.ValueString(),
+			"name":          data.Name.ValueString(),
+			"pubkey":        data.PublicKey.ValueString(),
 			"tunneladdress": data.AllowedIPs.ValueString(),
 		},
 	}
@@ -150,27 +244,12 @@ func (r *WireguardPeerResource) Create(ctx context.Context, req resource.CreateR
 		peerData["client"].(map[string]interface{})["keepalive"] = fmt.Sprintf("%d", data.Keepalive.ValueInt64())
 	}
 
-	jsonData, _ := json.Marshal(peerData)
-
-	url := fmt.Sprintf("%s/api/wireguard/client/add_client", r.client.Host)
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create peer: %s", err))
-		return
-	}
-	defer httpResp.Body.Close()
-
-	body, _ := io.ReadAll(httpResp.Body)
-
 	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to parse response: %s", err))
+	if err := r.client.Post(ctx, "wireguard/client/add_client", peerData, &result); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to create peer", err, wireguardPeerFields)
 		return
 	}
+	unlock()
 
 	if uuid, ok := result["uuid"].(string); ok {
 		data.ID = types.StringValue(uuid)
@@ -180,10 +259,7 @@ func (r *WireguardPeerResource) Create(ctx context.Context, req resource.CreateR
 	}
 
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/wireguard/service/reconfigure", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "wireguard/service/reconfigure", &resp.Diagnostics)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -195,19 +271,13 @@ func (r *WireguardPeerResource) Read(ctx context.Context, req resource.ReadReque
 		return
 	}
 
-	url := fmt.Sprintf("%s/api/wireguard/client/get_client/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read peer: %s", err))
+	err := r.client.Get(ctx, "wireguard/client/get_client/"+data.ID.ValueString(), nil)
+	if IsNotFound(err) {
+		resp.State.RemoveResource(ctx)
 		return
 	}
-	defer httpResp.Body.Close()
-
-	if httpResp.StatusCode == 404 {
-		resp.State.RemoveResource(ctx)
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Unable to read peer", err, wireguardPeerFields)
 		return
 	}
 
@@ -221,10 +291,16 @@ func (r *WireguardPeerResource) Update(ctx context.Context, req resource.UpdateR
 		return
 	}
 
+	unlock := r.allocateAddress(ctx, &data, &resp.Diagnostics)
+	defer unlock()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	peerData := map[string]interface{}{
 		"client": map[string]interface{}{
-			"name":       data.Name.ValueString(),
-			"pubkey":     data.PublicKey.ValueString(),
+			"name":          data.Name.ValueString(),
+			"pubkey":        data.PublicKey.ValueString(),
 			"tunneladdress": data.AllowedIPs.ValueString(),
 		},
 	}
@@ -253,25 +329,14 @@ func (r *WireguardPeerResource) Update(ctx context.Context, req resource.UpdateR
 		peerData["client"].(map[string]interface{})["keepalive"] = fmt.Sprintf("%d", data.Keepalive.ValueInt64())
 	}
 
-	jsonData, _ := json.Marshal(peerData)
-
-	url := fmt.Sprintf("%s/api/wireguard/client/set_client/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update peer: %s", err))
+	if err := r.client.Post(ctx, "wireguard/client/set_client/"+data.ID.ValueString(), peerData, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to update peer", err, wireguardPeerFields)
 		return
 	}
-	defer httpResp.Body.Close()
+	unlock()
 
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/wireguard/service/reconfigure", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "wireguard/service/reconfigure", &resp.Diagnostics)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -283,22 +348,13 @@ func (r *WireguardPeerResource) Delete(ctx context.Context, req resource.DeleteR
 		return
 	}
 
-	url := fmt.Sprintf("%s/api/wireguard/client/del_client/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, nil)
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete peer: %s", err))
+	if err := r.client.Post(ctx, "wireguard/client/del_client/"+data.ID.ValueString(), nil, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to delete peer", err, wireguardPeerFields)
 		return
 	}
-	defer httpResp.Body.Close()
 
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/wireguard/service/reconfigure", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "wireguard/service/reconfigure", &resp.Diagnostics)
 }
 
 func (r *WireguardPeerResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
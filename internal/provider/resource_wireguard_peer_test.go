@@ -0,0 +1,158 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+const (
+	testPeerPublicKey  = "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="
+	testPeerPublicKey2 = "HIgo9xNzJMWLKASShiTqIybxZ0U3wGLiUeJ1PKf8ykw="
+)
+
+const testWireguardServerConfig = `
+resource "opnsense_wireguard_server" "wg0" {
+  name           = "wg0"
+  listen_port    = 51820
+  tunnel_address = "10.20.30.1/24"
+}
+`
+
+func TestWireguardPeerResource(t *testing.T) {
+	f := newFakeOPNsense(t)
+
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: `
+resource "opnsense_wireguard_peer" "test" {
+  name        = "laptop"
+  public_key  = "` + testPeerPublicKey + `"
+  allowed_ips = "10.20.30.2/32"
+  keepalive   = 25
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkFakeField(f, "wireguard.client", "opnsense_wireguard_peer.test", "tunneladdress", "10.20.30.2/32"),
+				checkFakeField(f, "wireguard.client", "opnsense_wireguard_peer.test", "keepalive", "25"),
+				checkApplied(f, "wireguard/service/reconfigure"),
+			),
+		},
+		resource.TestStep{
+			Config: `
+resource "opnsense_wireguard_peer" "test" {
+  name        = "laptop"
+  enabled     = false
+  public_key  = "` + testPeerPublicKey + `"
+  allowed_ips = "10.20.30.3/32"
+  keepalive   = 25
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkFakeField(f, "wireguard.client", "opnsense_wireguard_peer.test", "tunneladdress", "10.20.30.3/32"),
+				checkFakeField(f, "wireguard.client", "opnsense_wireguard_peer.test", "enabled", "0"),
+			),
+		},
+	)
+}
+
+func TestWireguardPeerResourceAllocation(t *testing.T) {
+	f := newFakeOPNsense(t)
+	f.Put("wireguard.client", map[string]interface{}{
+		"name":          "existing",
+		"pubkey":        testPeerPublicKey2,
+		"tunneladdress": "10.20.30.2/32",
+	})
+
+	config := testWireguardServerConfig + `
+resource "opnsense_wireguard_peer" "test" {
+  name                 = "phone"
+  public_key           = "` + testPeerPublicKey + `"
+  allocate_from_server = opnsense_wireguard_server.wg0.id
+}
+`
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: config,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("opnsense_wireguard_peer.test", "allowed_ips", "10.20.30.3/32"),
+				checkFakeField(f, "wireguard.client", "opnsense_wireguard_peer.test", "tunneladdress", "10.20.30.3/32"),
+			),
+		},
+		resource.TestStep{
+			// The address stays put even once a lower one becomes free.
+			PreConfig: func() {
+				for uuid, item := range f.Items("wireguard.client") {
+					if item["name"] == "existing" {
+						f.Remove("wireguard.client", uuid)
+					}
+				}
+			},
+			Config:   config,
+			PlanOnly: true,
+		},
+	)
+}
+
+func TestWireguardPeerResourceConflictingAddresses(t *testing.T) {
+	f := newFakeOPNsense(t)
+
+	fakeTest(t, f, resource.TestStep{
+		Config: testWireguardServerConfig + `
+resource "opnsense_wireguard_peer" "test" {
+  name                 = "phone"
+  public_key           = "` + testPeerPublicKey + `"
+  allowed_ips          = "10.20.30.9/32"
+  allocate_from_server = opnsense_wireguard_server.wg0.id
+}
+`,
+		ExpectError: regexp.MustCompile(`Invalid\s+Attribute\s+Combination`),
+	})
+}
+
+func TestWireguardPeerResourceAllocationFromUnknownServer(t *testing.T) {
+	f := newFakeOPNsense(t)
+
+	// terraform_data hides the server ID behind a value that is unknown
+	// during plan whenever the terraform_data is replaced.
+	config := func(server string, revision int) string {
+		return testWireguardServerConfig + fmt.Sprintf(`
+resource "opnsense_wireguard_server" "wg1" {
+  name           = "wg1"
+  listen_port    = 51821
+  tunnel_address = "10.40.0.1/24"
+}
+
+resource "terraform_data" "server" {
+  input            = opnsense_wireguard_server.%s.id
+  triggers_replace = [%d]
+}
+
+resource "opnsense_wireguard_peer" "test" {
+  name                 = "phone"
+  public_key           = "`+testPeerPublicKey+`"
+  allocate_from_server = terraform_data.server.output
+}
+`, server, revision)
+	}
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: config("wg0", 1),
+			Check:  resource.TestCheckResourceAttr("opnsense_wireguard_peer.test", "allowed_ips", "10.20.30.2/32"),
+		},
+		resource.TestStep{
+			// Still the same server once known: the address is kept.
+			Config: config("wg0", 2),
+			Check:  resource.TestCheckResourceAttr("opnsense_wireguard_peer.test", "allowed_ips", "10.20.30.2/32"),
+		},
+		resource.TestStep{
+			Config: config("wg1", 3),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("opnsense_wireguard_peer.test", "allowed_ips", "10.40.0.2/32"),
+				checkFakeField(f, "wireguard.client", "opnsense_wireguard_peer.test", "tunneladdress", "10.40.0.2/32"),
+			),
+		},
+	)
+}
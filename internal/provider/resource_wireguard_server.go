@@ -2,12 +2,10 @@ package provider
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -18,6 +16,7 @@ import (
 
 var _ resource.Resource = &WireguardServerResource{}
 var _ resource.ResourceWithImportState = &WireguardServerResource{}
+var _ resource.ResourceWithModifyPlan = &WireguardServerResource{}
 
 func NewWireguardServerResource() resource.Resource {
 	return &WireguardServerResource{}
@@ -40,6 +39,31 @@ type WireguardServerResourceModel struct {
 	DNS           types.String `tfsdk:"dns"`
 	MTU           types.Int64  `tfsdk:"mtu"`
 	Gateway       types.String `tfsdk:"gateway"`
+	StoreKey      types.Bool   `tfsdk:"store_private_key"`
+	KeyFile       types.String `tfsdk:"private_key_file"`
+	KeyWO         types.String `tfsdk:"private_key_wo"`
+	KeyWOVersion  types.Int64  `tfsdk:"private_key_wo_version"`
+}
+
+// storesPrivateKey reports whether private_key is kept in state. The
+// write-only key is never in the plan or state, so its version stands in.
+func (m WireguardServerResourceModel) storesPrivateKey() bool {
+	return m.KeyFile.IsNull() && m.KeyWOVersion.IsNull() && (m.StoreKey.IsNull() || m.StoreKey.ValueBool())
+}
+
+// wireguardServerFields maps OPNsense server fields to resource attributes.
+var wireguardServerFields = map[string]string{
+	"name":          "name",
+	"enabled":       "enabled",
+	"pubkey":        "public_key",
+	"privkey":       "private_key",
+	"port":          "listen_port",
+	"tunneladdress": "tunnel_address",
+	"peers":         "peers",
+	"disableroutes": "disable_routes",
+	"dns":           "dns",
+	"mtu":           "mtu",
+	"gateway":       "gateway",
 }
 
 func (r *WireguardServerResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -69,12 +93,18 @@ func (r *WireguardServerResource) Schema(ctx context.Context, req resource.Schem
 			"public_key": schema.StringAttribute{
 				MarkdownDescription: "Server public key",
 				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"private_key": schema.StringAttribute{
-				MarkdownDescription: "Server private key (auto-generated if not provided)",
+				MarkdownDescription: "Server private key (auto-generated if not provided). Stored in state; see `private_key_wo`, `store_private_key` and `private_key_file` to avoid that",
 				Optional:            true,
 				Computed:            true,
 				Sensitive:           true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"listen_port": schema.Int64Attribute{
 				MarkdownDescription: "UDP port to listen on",
@@ -85,7 +115,7 @@ func (r *WireguardServerResource) Schema(ctx context.Context, req resource.Schem
 				Required:            true,
 			},
 			"peers": schema.ListAttribute{
-				MarkdownDescription: "List of peer UUIDs",
+				MarkdownDescription: "List of peer UUIDs. Leave unset when peers are attached with `opnsense_wireguard_server_peer_attachment`",
 				Optional:            true,
 				ElementType:         types.StringType,
 			},
@@ -105,6 +135,30 @@ func (r *WireguardServerResource) Schema(ctx context.Context, req resource.Schem
 				MarkdownDescription: "Gateway IP address for the tunnel",
 				Optional:            true,
 			},
+			"private_key_wo": schema.StringAttribute{
+				MarkdownDescription: "Write-only private key, never stored in state or plan; `public_key` is derived from it. " +
+					"Can be set from an ephemeral resource. Requires Terraform 1.11 or later and `private_key_wo_version`",
+				Optional:  true,
+				Sensitive: true,
+				WriteOnly: true,
+			},
+			"private_key_wo_version": schema.Int64Attribute{
+				MarkdownDescription: "Version of `private_key_wo`. Terraform cannot see changes of a write-only value, " +
+					"so change this to send a new key",
+				Optional: true,
+			},
+			"store_private_key": schema.BoolAttribute{
+				MarkdownDescription: "Keep the private key in state. When false, OPNsense generates the keypair (`wireguard/server/key_pair`) " +
+					"and only `public_key` is stored. Default is true. Unlike `private_key_wo`, this works with Terraform " +
+					"older than 1.11, which has no write-only attributes",
+				Optional: true,
+			},
+			"private_key_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a file holding the private key (e.g., the output of `wg genkey`). " +
+					"The key is read during plan and apply and never stored in state; `public_key` is derived from it. " +
+					"Like `store_private_key`, this is meant for Terraform older than 1.11; prefer `private_key_wo` otherwise",
+				Optional: true,
+			},
 		},
 	}
 }
@@ -126,6 +180,109 @@ func (r *WireguardServerResource) Configure(ctx context.Context, req resource.Co
 	r.client = client
 }
 
+// ModifyPlan fails the plan when the firewall does not provide WireGuard
+// and plans the key attributes: private_key is dropped when it must not be
+// stored, and public_key follows a new private key, write-only key or key
+// file.
+func (r *WireguardServerResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	r.client.requireSubsystem(subsystemWireguard, &resp.Diagnostics)
+
+	var plan, config WireguardServerResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.PrivateKey.IsNull() && !plan.storesPrivateKey() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("private_key"),
+			"Conflicting Private Key Settings",
+			"private_key cannot be combined with private_key_wo, private_key_file or store_private_key = false.",
+		)
+		return
+	}
+	if config.KeyWO.IsNull() != config.KeyWOVersion.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("private_key_wo_version"),
+			"Missing Private Key Version",
+			"private_key_wo and private_key_wo_version must be set together.",
+		)
+		return
+	}
+	if !config.KeyWO.IsNull() && !config.KeyFile.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("private_key_wo"),
+			"Conflicting Private Key Settings",
+			"private_key_wo cannot be combined with private_key_file.",
+		)
+		return
+	}
+
+	if !plan.storesPrivateKey() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("private_key"), types.StringNull())...)
+	}
+
+	if config.KeyWO.IsUnknown() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("public_key"), types.StringUnknown())...)
+		return
+	}
+	if !config.KeyWO.IsNull() {
+		publicKey, err := wireguardPublicKey(config.KeyWO.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("private_key_wo"), "Invalid Private Key", err.Error())
+			return
+		}
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("public_key"), types.StringValue(publicKey))...)
+		return
+	}
+
+	if !plan.KeyFile.IsNull() && !plan.KeyFile.IsUnknown() {
+		key, err := readWireguardKeyFile(plan.KeyFile.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("private_key_file"), "Unable to Read Private Key File", err.Error())
+			return
+		}
+		publicKey, _ := wireguardPublicKey(key)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("public_key"), types.StringValue(publicKey))...)
+		return
+	}
+
+	if req.State.Raw.IsNull() {
+		return
+	}
+	var state WireguardServerResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if plan.storesPrivateKey() && !plan.PrivateKey.Equal(state.PrivateKey) {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("public_key"), types.StringUnknown())...)
+	}
+}
+
+// privateKeyToSend returns the private key to write to OPNsense, if any.
+// keyWO is the write-only key, which is only in the configuration. On create
+// without a configured key, a keypair is generated when the key must not be
+// stored; otherwise OPNsense generates one itself.
+func (r *WireguardServerResource) privateKeyToSend(ctx context.Context, data *WireguardServerResourceModel, keyWO types.String, create bool) (string, error) {
+	switch {
+	case !keyWO.IsNull():
+		return keyWO.ValueString(), nil
+	case !data.KeyFile.IsNull():
+		return readWireguardKeyFile(data.KeyFile.ValueString())
+	case !data.PrivateKey.IsNull() && !data.PrivateKey.IsUnknown():
+		return data.PrivateKey.ValueString(), nil
+	case create && !data.storesPrivateKey():
+		privateKey, _, err := r.client.generateWireguardKeyPair(ctx)
+		return privateKey, err
+	}
+	return "", nil
+}
+
 func (r *WireguardServerResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var data WireguardServerResourceModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
@@ -135,9 +292,9 @@ func (r *WireguardServerResource) Create(ctx context.Context, req resource.Creat
 
 	serverData := map[string]interface{}{
 		"server": map[string]interface{}{
-			"name":           data.Name.ValueString(),
-			"port":           fmt.Sprintf("%d", data.ListenPort.ValueInt64()),
-			"tunneladdress":  data.TunnelAddr.ValueString(),
+			"name":          data.Name.ValueString(),
+			"port":          fmt.Sprintf("%d", data.ListenPort.ValueInt64()),
+			"tunneladdress": data.TunnelAddr.ValueString(),
 		},
 	}
 
@@ -151,12 +308,32 @@ func (r *WireguardServerResource) Create(ctx context.Context, req resource.Creat
 		serverData["server"].(map[string]interface{})["enabled"] = "1"
 	}
 
-	if !data.PrivateKey.IsNull() {
-		serverData["server"].(map[string]interface{})["privkey"] = data.PrivateKey.ValueString()
+	var keyWO types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("private_key_wo"), &keyWO)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	privateKey, err := r.privateKeyToSend(ctx, &data, keyWO, true)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to determine private key", err.Error())
+		return
+	}
+	if privateKey != "" {
+		publicKey, err := wireguardPublicKey(privateKey)
+		if err != nil {
+			resp.Diagnostics.AddError("Invalid Private Key", err.Error())
+			return
+		}
+		serverData["server"].(map[string]interface{})["privkey"] = privateKey
+		serverData["server"].(map[string]interface{})["pubkey"] = publicKey
 	}
 
-	if !data.DisableRoutes.IsNull() && data.DisableRoutes.ValueBool() {
-		serverData["server"].(map[string]interface{})["disableroutes"] = "1"
+	if !data.DisableRoutes.IsNull() {
+		if data.DisableRoutes.ValueBool() {
+			serverData["server"].(map[string]interface{})["disableroutes"] = "1"
+		} else {
+			serverData["server"].(map[string]interface{})["disableroutes"] = "0"
+		}
 	}
 
 	if !data.Peers.IsNull() {
@@ -177,25 +354,9 @@ func (r *WireguardServerResource) Create(ctx context.Context, req resource.Creat
 		serverData["server"].(map[string]interface{})["gateway"] = data.Gateway.ValueString()
 	}
 
-	jsonData, _ := json.Marshal(serverData)
-
-	url := fmt.Sprintf("%s/api/wireguard/server/add_server", r.client.Host)
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create server: %s", err))
-		return
-	}
-	defer httpResp.Body.Close()
-
-	body, _ := io.ReadAll(httpResp.Body)
-
 	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to parse response: %s", err))
+	if err := r.client.Post(ctx, "wireguard/server/add_server", serverData, &result); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to create server", err, wireguardServerFields)
 		return
 	}
 
@@ -207,45 +368,35 @@ func (r *WireguardServerResource) Create(ctx context.Context, req resource.Creat
 	}
 
 	// Read back to get generated keys
-	r.readServerKeys(ctx, &data)
+	r.readServerKeys(ctx, &data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/wireguard/service/reconfigure", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "wireguard/service/reconfigure", &resp.Diagnostics)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
-func (r *WireguardServerResource) readServerKeys(ctx context.Context, data *WireguardServerResourceModel) {
-	url := fmt.Sprintf("%s/api/wireguard/server/get_server/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
+// readServerKeys fills in the keys OPNsense generated or derived, so the
+// computed attributes are known after apply.
+func (r *WireguardServerResource) readServerKeys(ctx context.Context, data *WireguardServerResourceModel, diags *diag.Diagnostics) {
+	server, err := r.client.GetItem(ctx, "wireguard/server/get_server/"+data.ID.ValueString(), "server")
 	if err != nil {
+		addClientError(diags, "Unable to read server keys", err, wireguardServerFields)
 		return
 	}
-	defer httpResp.Body.Close()
-
-	if httpResp.StatusCode != 200 {
+	if server == nil {
+		diags.AddError("Unable to read server keys", fmt.Sprintf("Server %s was not found after it was written.", data.ID.ValueString()))
 		return
 	}
 
-	body, _ := io.ReadAll(httpResp.Body)
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return
-	}
-
-	if server, ok := result["server"].(map[string]interface{}); ok {
-		if pubkey, ok := server["pubkey"].(string); ok {
-			data.PublicKey = types.StringValue(pubkey)
-		}
-		if privkey, ok := server["privkey"].(string); ok && data.PrivateKey.IsNull() {
-			data.PrivateKey = types.StringValue(privkey)
-		}
+	data.PublicKey = types.StringValue(stringValue(server["pubkey"]))
+	if !data.storesPrivateKey() {
+		data.PrivateKey = types.StringNull()
+	} else if data.PrivateKey.IsNull() || data.PrivateKey.IsUnknown() {
+		data.PrivateKey = types.StringValue(stringValue(server["privkey"]))
 	}
 }
 
@@ -256,22 +407,46 @@ func (r *WireguardServerResource) Read(ctx context.Context, req resource.ReadReq
 		return
 	}
 
-	url := fmt.Sprintf("%s/api/wireguard/server/get_server/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
+	server, err := r.client.GetItem(ctx, "wireguard/server/get_server/"+data.ID.ValueString(), "server")
 	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read server: %s", err))
+		addClientError(&resp.Diagnostics, "Unable to read server", err, wireguardServerFields)
 		return
 	}
-	defer httpResp.Body.Close()
-
-	if httpResp.StatusCode == 404 {
+	if server == nil {
 		resp.State.RemoveResource(ctx)
 		return
 	}
 
+	data.Name = types.StringValue(stringValue(server["name"]))
+	data.Enabled = refreshBool(data.Enabled, boolValue(server["enabled"]), true)
+	// A key rotated in the GUI shows up as a change of both keys
+	data.PublicKey = types.StringValue(stringValue(server["pubkey"]))
+	if data.storesPrivateKey() {
+		data.PrivateKey = types.StringValue(stringValue(server["privkey"]))
+	} else {
+		data.PrivateKey = types.StringNull()
+	}
+	if port, ok := int64Value(server["port"]); ok {
+		data.ListenPort = types.Int64Value(port)
+	}
+	if addr := stringValue(server["tunneladdress"]); !sameElements(splitList(addr), splitList(data.TunnelAddr.ValueString())) {
+		data.TunnelAddr = types.StringValue(addr)
+	}
+	// peers is a selected-options map of every client; the selection is
+	// compared as a set. Left unset, the list is not managed here, e.g.
+	// because peers are attached with opnsense_wireguard_server_peer_attachment.
+	if !data.Peers.IsNull() {
+		data.Peers = refreshStringList(ctx, data.Peers, selectedOptions(server["peers"]), &resp.Diagnostics)
+	}
+	data.DisableRoutes = refreshBool(data.DisableRoutes, boolValue(server["disableroutes"]), false)
+	data.DNS = refreshString(data.DNS, stringValue(server["dns"]), "")
+	mtu, ok := int64Value(server["mtu"])
+	data.MTU = refreshInt64(data.MTU, mtu, ok, 0)
+	data.Gateway = refreshString(data.Gateway, stringValue(server["gateway"]), "")
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
@@ -284,9 +459,9 @@ func (r *WireguardServerResource) Update(ctx context.Context, req resource.Updat
 
 	serverData := map[string]interface{}{
 		"server": map[string]interface{}{
-			"name":           data.Name.ValueString(),
-			"port":           fmt.Sprintf("%d", data.ListenPort.ValueInt64()),
-			"tunneladdress":  data.TunnelAddr.ValueString(),
+			"name":          data.Name.ValueString(),
+			"port":          fmt.Sprintf("%d", data.ListenPort.ValueInt64()),
+			"tunneladdress": data.TunnelAddr.ValueString(),
 		},
 	}
 
@@ -298,12 +473,32 @@ func (r *WireguardServerResource) Update(ctx context.Context, req resource.Updat
 		}
 	}
 
-	if !data.PrivateKey.IsNull() {
-		serverData["server"].(map[string]interface{})["privkey"] = data.PrivateKey.ValueString()
+	var keyWO types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("private_key_wo"), &keyWO)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	privateKey, err := r.privateKeyToSend(ctx, &data, keyWO, false)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to determine private key", err.Error())
+		return
+	}
+	if privateKey != "" {
+		publicKey, err := wireguardPublicKey(privateKey)
+		if err != nil {
+			resp.Diagnostics.AddError("Invalid Private Key", err.Error())
+			return
+		}
+		serverData["server"].(map[string]interface{})["privkey"] = privateKey
+		serverData["server"].(map[string]interface{})["pubkey"] = publicKey
 	}
 
-	if !data.DisableRoutes.IsNull() && data.DisableRoutes.ValueBool() {
-		serverData["server"].(map[string]interface{})["disableroutes"] = "1"
+	if !data.DisableRoutes.IsNull() {
+		if data.DisableRoutes.ValueBool() {
+			serverData["server"].(map[string]interface{})["disableroutes"] = "1"
+		} else {
+			serverData["server"].(map[string]interface{})["disableroutes"] = "0"
+		}
 	}
 
 	if !data.Peers.IsNull() {
@@ -324,25 +519,19 @@ func (r *WireguardServerResource) Update(ctx context.Context, req resource.Updat
 		serverData["server"].(map[string]interface{})["gateway"] = data.Gateway.ValueString()
 	}
 
-	jsonData, _ := json.Marshal(serverData)
-
-	url := fmt.Sprintf("%s/api/wireguard/server/set_server/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	httpReq.Header.Set("Content-Type", "application/json")
+	if err := r.client.Post(ctx, "wireguard/server/set_server/"+data.ID.ValueString(), serverData, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to update server", err, wireguardServerFields)
+		return
+	}
 
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update server: %s", err))
+	// A new private key changes the public key
+	r.readServerKeys(ctx, &data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
 		return
 	}
-	defer httpResp.Body.Close()
 
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/wireguard/service/reconfigure", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "wireguard/service/reconfigure", &resp.Diagnostics)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -354,22 +543,13 @@ func (r *WireguardServerResource) Delete(ctx context.Context, req resource.Delet
 		return
 	}
 
-	url := fmt.Sprintf("%s/api/wireguard/server/del_server/%s", r.client.Host, data.ID.ValueString())
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", url, nil)
-	httpReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-
-	httpResp, err := r.client.client.Do(httpReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete server: %s", err))
+	if err := r.client.Post(ctx, "wireguard/server/del_server/"+data.ID.ValueString(), nil, nil); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to delete server", err, wireguardServerFields)
 		return
 	}
-	defer httpResp.Body.Close()
 
 	// Apply configuration
-	applyURL := fmt.Sprintf("%s/api/wireguard/service/reconfigure", r.client.Host)
-	applyReq, _ := http.NewRequestWithContext(ctx, "POST", applyURL, nil)
-	applyReq.SetBasicAuth(r.client.ApiKey, r.client.ApiSecret)
-	r.client.client.Do(applyReq)
+	r.client.applyChanges(ctx, "wireguard/service/reconfigure", &resp.Diagnostics)
 }
 
 func (r *WireguardServerResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
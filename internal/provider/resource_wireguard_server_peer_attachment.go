@@ -0,0 +1,248 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var _ resource.Resource = &WireguardServerPeerAttachmentResource{}
+var _ resource.ResourceWithImportState = &WireguardServerPeerAttachmentResource{}
+var _ resource.ResourceWithModifyPlan = &WireguardServerPeerAttachmentResource{}
+
+// peerAttachmentAttempts bounds how often an attachment is rewritten when a
+// concurrent writer outside this provider replaced the server's peer list
+// between our read and write.
+const peerAttachmentAttempts = 3
+
+func NewWireguardServerPeerAttachmentResource() resource.Resource {
+	return &WireguardServerPeerAttachmentResource{}
+}
+
+type WireguardServerPeerAttachmentResource struct {
+	client *Client
+}
+
+type WireguardServerPeerAttachmentResourceModel struct {
+	ID       types.String `tfsdk:"id"`
+	ServerID types.String `tfsdk:"server_id"`
+	PeerID   types.String `tfsdk:"peer_id"`
+}
+
+// wireguardPeerAttachmentFields maps OPNsense server fields to resource
+// attributes.
+var wireguardPeerAttachmentFields = map[string]string{
+	"peers": "peer_id",
+}
+
+func (r *WireguardServerPeerAttachmentResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_wireguard_server_peer_attachment"
+}
+
+func (r *WireguardServerPeerAttachmentResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Attaches a WireGuard peer to a server without managing the server's whole peer list",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Attachment ID in the form `server_id/peer_id`",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"server_id": schema.StringAttribute{
+				MarkdownDescription: "UUID of the WireGuard server",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"peer_id": schema.StringAttribute{
+				MarkdownDescription: "UUID of the WireGuard peer",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+		},
+	}
+}
+
+func (r *WireguardServerPeerAttachmentResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *Client, got: %T.", req.ProviderData),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+// ModifyPlan fails the plan when the firewall does not provide WireGuard.
+func (r *WireguardServerPeerAttachmentResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	r.client.requireSubsystem(subsystemWireguard, &resp.Diagnostics)
+}
+
+func (r *WireguardServerPeerAttachmentResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data WireguardServerPeerAttachmentResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.setAttached(ctx, data.ServerID.ValueString(), data.PeerID.ValueString(), true); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to attach peer", err, wireguardPeerAttachmentFields)
+		return
+	}
+	data.ID = types.StringValue(data.ServerID.ValueString() + "/" + data.PeerID.ValueString())
+
+	// Apply configuration
+	r.client.applyChanges(ctx, "wireguard/service/reconfigure", &resp.Diagnostics)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *WireguardServerPeerAttachmentResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data WireguardServerPeerAttachmentResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	peers, found, err := r.serverPeers(ctx, data.ServerID.ValueString())
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Unable to read server", err, wireguardPeerAttachmentFields)
+		return
+	}
+	if !found || !slices.Contains(peers, data.PeerID.ValueString()) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update is never called with a change: both attributes require replacement.
+func (r *WireguardServerPeerAttachmentResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data WireguardServerPeerAttachmentResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *WireguardServerPeerAttachmentResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data WireguardServerPeerAttachmentResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.setAttached(ctx, data.ServerID.ValueString(), data.PeerID.ValueString(), false); err != nil {
+		addClientError(&resp.Diagnostics, "Unable to detach peer", err, wireguardPeerAttachmentFields)
+		return
+	}
+
+	// Apply configuration
+	r.client.applyChanges(ctx, "wireguard/service/reconfigure", &resp.Diagnostics)
+}
+
+func (r *WireguardServerPeerAttachmentResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	serverID, peerID, ok := strings.Cut(req.ID, "/")
+	if !ok || serverID == "" || peerID == "" {
+		resp.Diagnostics.AddError(
+			"Invalid Import ID",
+			fmt.Sprintf("Expected an import ID of the form server_id/peer_id, got: %q.", req.ID),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("server_id"), serverID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("peer_id"), peerID)...)
+}
+
+// serverPeers returns the UUIDs of the peers selected on a server. found is
+// false when the server does not exist.
+func (r *WireguardServerPeerAttachmentResource) serverPeers(ctx context.Context, serverID string) (peers []string, found bool, err error) {
+	server, err := r.client.GetItem(ctx, "wireguard/server/get_server/"+serverID, "server")
+	if err != nil || server == nil {
+		return nil, false, err
+	}
+	return selectedOptions(server["peers"]), true, nil
+}
+
+// setAttached adds peerID to or removes it from the server's peer list with
+// a read-modify-write. Attachments made through this provider are
+// serialized; a change by another writer in between is detected by reading
+// the list back and the write is repeated.
+func (r *WireguardServerPeerAttachmentResource) setAttached(ctx context.Context, serverID, peerID string, attach bool) error {
+	r.client.serverPeers.Lock()
+	defer r.client.serverPeers.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		peers, found, err := r.serverPeers(ctx, serverID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			if !attach {
+				return nil
+			}
+			return fmt.Errorf("WireGuard server %q not found", serverID)
+		}
+		if slices.Contains(peers, peerID) == attach {
+			return nil
+		}
+		if attempt > peerAttachmentAttempts {
+			return fmt.Errorf("the peer list of WireGuard server %q kept changing; giving up after %d attempts", serverID, peerAttachmentAttempts)
+		}
+		if attempt > 1 {
+			tflog.Debug(ctx, "WireGuard server peers changed concurrently, retrying", map[string]any{
+				"server":  serverID,
+				"attempt": attempt,
+			})
+		}
+
+		var updated []string
+		for _, p := range peers {
+			if p != peerID {
+				updated = append(updated, p)
+			}
+		}
+		if attach {
+			updated = append(updated, peerID)
+		}
+
+		payload := map[string]interface{}{
+			"server": map[string]interface{}{
+				"peers": strings.Join(updated, ","),
+			},
+		}
+		if err := r.client.Post(ctx, "wireguard/server/set_server/"+serverID, payload, nil); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// checkServerPeers asserts how many peers are selected on the server.
+func checkServerPeers(f *fakeOPNsense, want int) resource.TestCheckFunc {
+	return checkFakeItem(f, "wireguard.server", "opnsense_wireguard_server.wg0", func(item map[string]interface{}) error {
+		if got := len(splitList(fakeString(item["peers"]))); got != want {
+			return fmt.Errorf("server has %d peer(s), want %d", got, want)
+		}
+		return nil
+	})
+}
+
+func TestWireguardServerPeerAttachmentResource(t *testing.T) {
+	f := newFakeOPNsense(t)
+
+	peers := testWireguardServerConfig + `
+resource "opnsense_wireguard_peer" "a" {
+  name        = "a"
+  public_key  = "` + testPeerPublicKey + `"
+  allowed_ips = "10.20.30.2/32"
+}
+
+resource "opnsense_wireguard_peer" "b" {
+  name        = "b"
+  public_key  = "` + testPeerPublicKey2 + `"
+  allowed_ips = "10.20.30.3/32"
+}
+`
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: peers + `
+resource "opnsense_wireguard_server_peer_attachment" "a" {
+  server_id = opnsense_wireguard_server.wg0.id
+  peer_id   = opnsense_wireguard_peer.a.id
+}
+
+resource "opnsense_wireguard_server_peer_attachment" "b" {
+  server_id = opnsense_wireguard_server.wg0.id
+  peer_id   = opnsense_wireguard_peer.b.id
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkServerPeers(f, 2),
+				resource.TestCheckNoResourceAttr("opnsense_wireguard_server.wg0", "peers.#"),
+			),
+		},
+		resource.TestStep{
+			ResourceName:      "opnsense_wireguard_server_peer_attachment.a",
+			ImportState:       true,
+			ImportStateVerify: true,
+			ImportStateIdFunc: func(s *terraform.State) (string, error) {
+				return s.RootModule().Resources["opnsense_wireguard_server_peer_attachment.a"].Primary.ID, nil
+			},
+		},
+		resource.TestStep{
+			Config: peers + `
+resource "opnsense_wireguard_server_peer_attachment" "b" {
+  server_id = opnsense_wireguard_server.wg0.id
+  peer_id   = opnsense_wireguard_peer.b.id
+}
+`,
+			Check: checkServerPeers(f, 1),
+		},
+	)
+}
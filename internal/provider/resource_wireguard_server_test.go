@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestWireguardServerResource(t *testing.T) {
+	f := newFakeOPNsense(t)
+
+	fakeTest(t, f,
+		resource.TestStep{
+			Config: `
+resource "opnsense_wireguard_server" "test" {
+  name           = "wg0"
+  listen_port    = 51820
+  tunnel_address = "10.20.30.1/24"
+  dns            = "10.20.30.1"
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttrSet("opnsense_wireguard_server.test", "public_key"),
+				resource.TestCheckResourceAttrSet("opnsense_wireguard_server.test", "private_key"),
+				checkFakeField(f, "wireguard.server", "opnsense_wireguard_server.test", "port", "51820"),
+				checkApplied(f, "wireguard/service/reconfigure"),
+			),
+		},
+		resource.TestStep{
+			Config: `
+resource "opnsense_wireguard_server" "test" {
+  name           = "wg0"
+  listen_port    = 51821
+  tunnel_address = "10.20.30.1/24"
+  dns            = "10.20.30.1"
+  mtu            = 1420
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				checkFakeField(f, "wireguard.server", "opnsense_wireguard_server.test", "port", "51821"),
+				checkFakeField(f, "wireguard.server", "opnsense_wireguard_server.test", "mtu", "1420"),
+			),
+		},
+		resource.TestStep{
+			ResourceName:      "opnsense_wireguard_server.test",
+			ImportState:       true,
+			ImportStateVerify: true,
+		},
+	)
+}
+
+func TestWireguardServerResourceWithoutStoredKey(t *testing.T) {
+	f := newFakeOPNsense(t)
+
+	fakeTest(t, f, resource.TestStep{
+		Config: `
+resource "opnsense_wireguard_server" "test" {
+  name              = "wg0"
+  listen_port       = 51820
+  tunnel_address    = "10.20.30.1/24"
+  store_private_key = false
+}
+`,
+		Check: resource.ComposeAggregateTestCheckFunc(
+			resource.TestCheckNoResourceAttr("opnsense_wireguard_server.test", "private_key"),
+			func(s *terraform.State) error {
+				rs := s.RootModule().Resources["opnsense_wireguard_server.test"]
+				item := f.Item("wireguard.server", rs.Primary.ID)
+				want, err := wireguardPublicKey(fakeString(item["privkey"]))
+				if err != nil {
+					return err
+				}
+				if got := rs.Primary.Attributes["public_key"]; got != want {
+					return fmt.Errorf("public_key = %q, want %q", got, want)
+				}
+				return nil
+			},
+		),
+	})
+}
+
+func TestWireguardServerResourceWriteOnlyKey(t *testing.T) {
+	f := newFakeOPNsense(t)
+	first, second := testWireguardKey(t), testWireguardKey(t)
+
+	config := func(key string, version int) string {
+		return fmt.Sprintf(`
+resource "opnsense_wireguard_server" "test" {
+  name                   = "wg0"
+  listen_port            = 51820
+  tunnel_address         = "10.20.30.1/24"
+  private_key_wo         = %q
+  private_key_wo_version = %d
+}
+`, key, version)
+	}
+	check := func(key string) resource.TestCheckFunc {
+		publicKey, _ := wireguardPublicKey(key)
+		return resource.ComposeAggregateTestCheckFunc(
+			resource.TestCheckNoResourceAttr("opnsense_wireguard_server.test", "private_key"),
+			resource.TestCheckNoResourceAttr("opnsense_wireguard_server.test", "private_key_wo"),
+			resource.TestCheckResourceAttr("opnsense_wireguard_server.test", "public_key", publicKey),
+			checkFakeField(f, "wireguard.server", "opnsense_wireguard_server.test", "privkey", key),
+		)
+	}
+
+	fakeTestCase(t, f, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_11_0),
+		},
+		Steps: []resource.TestStep{
+			{Config: config(first, 1), Check: check(first)},
+			// Only a new version sends the new key.
+			{Config: config(second, 2), Check: check(second)},
+			{
+				Config: `
+resource "opnsense_wireguard_server" "test" {
+  name           = "wg0"
+  listen_port    = 51820
+  tunnel_address = "10.20.30.1/24"
+  private_key_wo = "` + first + `"
+}
+`,
+				ExpectError: regexp.MustCompile("private_key_wo and private_key_wo_version must be set together"),
+			},
+		},
+	})
+}
+
+func TestWireguardServerResourceKeyReadError(t *testing.T) {
+	f := newFakeOPNsense(t)
+	f.FailNext("wireguard/server/get_server", http.StatusForbidden)
+
+	fakeTest(t, f, resource.TestStep{
+		Config: `
+resource "opnsense_wireguard_server" "test" {
+  name           = "wg0"
+  listen_port    = 51820
+  tunnel_address = "10.20.30.1/24"
+}
+`,
+		ExpectError: regexp.MustCompile("Unable to read server keys"),
+	})
+}
+
+// testWireguardKey returns a new base64 WireGuard private key.
+func testWireguardKey(t *testing.T) string {
+	t.Helper()
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(key.Bytes())
+}
@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Retry defaults used when the provider configuration leaves them unset.
+const (
+	defaultMaxRetries   = 3
+	defaultRetryWaitMin = 1 * time.Second
+	defaultRetryWaitMax = 30 * time.Second
+)
+
+// isIdempotent reports whether a request can be repeated without side
+// effects. Creating ("add…") and toggling objects are not: a retried addRule
+// whose first attempt did reach OPNsense would create a duplicate.
+func isIdempotent(method, endpoint string) bool {
+	if method == http.MethodGet {
+		return true
+	}
+
+	parts := strings.Split(strings.Trim(endpoint, "/"), "/")
+	action := ""
+	if len(parts) >= 3 {
+		action = strings.ToLower(parts[2])
+	}
+	return !strings.HasPrefix(action, "add") && !strings.HasPrefix(action, "toggle")
+}
+
+// shouldRetry reports whether a failed attempt is worth repeating. Requests
+// that are not idempotent are only retried when they cannot have been
+// processed: the connection was never established or OPNsense rate-limited
+// the call.
+func shouldRetry(method, endpoint string, statusCode int, err error) bool {
+	idempotent := isIdempotent(method, endpoint)
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return true
+		}
+		if !idempotent {
+			return false
+		}
+		return errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, syscall.ECONNREFUSED) ||
+			errors.Is(err, io.EOF) ||
+			errors.Is(err, io.ErrUnexpectedEOF) ||
+			errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusInternalServerError:
+		return idempotent
+	}
+	return false
+}
+
+// retryBackoff returns how long to wait before retry number attempt
+// (starting at 1): exponential growth from min, capped at max, with jitter
+// so parallel resources do not retry in lockstep. A Retry-After header
+// takes precedence when present.
+func retryBackoff(attempt int, min, max time.Duration, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			if wait := time.Duration(secs) * time.Second; wait <= max {
+				return wait
+			}
+			return max
+		}
+	}
+
+	wait := min
+	for i := 1; i < attempt && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+	if wait <= 0 {
+		return 0
+	}
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(wait-half)+1))
+}
+
+// rateLimiter spaces requests at least interval apart. The zero value does
+// not limit.
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// Wait blocks until the next request may be sent or ctx is done.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil || l.interval <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	return sleepContext(ctx, time.Until(slot))
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
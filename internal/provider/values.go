@@ -0,0 +1,216 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Helpers for decoding the values returned by OPNsense "get" endpoints. Plain
+// fields come back as strings ("1", "443"), while option fields are encoded as
+// {"key": {"value": "Label", "selected": 1}, ...}.
+
+// selectedOptions returns the keys marked as selected in an option map. Plain
+// strings are split on commas so list-style fields decode the same way.
+func selectedOptions(v interface{}) []string {
+	var selected []string
+	switch opts := v.(type) {
+	case map[string]interface{}:
+		for key, raw := range opts {
+			opt, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if isSelected(opt["selected"]) && key != "" {
+				selected = append(selected, key)
+			}
+		}
+		sort.Strings(selected)
+	case string:
+		for _, item := range strings.Split(opts, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				selected = append(selected, item)
+			}
+		}
+	case []interface{}:
+		for _, item := range opts {
+			if s, ok := item.(string); ok && s != "" {
+				selected = append(selected, s)
+			}
+		}
+	}
+	return selected
+}
+
+// splitList splits a comma-separated field into its trimmed, non-empty items.
+func splitList(s string) []string {
+	return selectedOptions(s)
+}
+
+// selectedOption returns the selected key(s) of an option field joined by commas.
+func selectedOption(v interface{}) string {
+	return strings.Join(selectedOptions(v), ",")
+}
+
+func isSelected(v interface{}) bool {
+	switch s := v.(type) {
+	case bool:
+		return s
+	case float64:
+		return s != 0
+	case string:
+		return s == "1" || strings.EqualFold(s, "true")
+	}
+	return false
+}
+
+// stringValue returns a plain string field, falling back to the selected
+// option for fields OPNsense renders as an option map.
+func stringValue(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case map[string]interface{}:
+		return selectedOption(s)
+	}
+	return ""
+}
+
+// boolValue decodes OPNsense's "1"/"0" booleans.
+func boolValue(v interface{}) bool {
+	return isSelected(v)
+}
+
+// boolFlag encodes a boolean attribute as "1" or "0" for a set endpoint,
+// using def when the attribute is null.
+func boolFlag(v types.Bool, def bool) string {
+	if !v.IsNull() && !v.IsUnknown() {
+		def = v.ValueBool()
+	}
+	if def {
+		return "1"
+	}
+	return "0"
+}
+
+// int64Value decodes a numeric string field; ok is false when it is empty.
+func int64Value(v interface{}) (int64, bool) {
+	n, err := strconv.ParseInt(strings.TrimSpace(stringValue(v)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// refreshString returns the value to store for an optional attribute. An
+// attribute the practitioner never set stays null while OPNsense reports the
+// empty value or def; values differing only in case keep the prior spelling.
+func refreshString(prior types.String, value, def string) types.String {
+	if prior.IsNull() && (value == "" || value == def) {
+		return types.StringNull()
+	}
+	if !prior.IsNull() && !prior.IsUnknown() && strings.EqualFold(prior.ValueString(), value) {
+		return prior
+	}
+	return types.StringValue(value)
+}
+
+// refreshRequiredString returns value for a required attribute, keeping the
+// prior spelling when the two differ only in case.
+func refreshRequiredString(prior types.String, value string) types.String {
+	if !prior.IsNull() && !prior.IsUnknown() && strings.EqualFold(prior.ValueString(), value) {
+		return prior
+	}
+	return types.StringValue(value)
+}
+
+// refreshBool is the boolean counterpart of refreshString.
+func refreshBool(prior types.Bool, value, def bool) types.Bool {
+	if prior.IsNull() && value == def {
+		return types.BoolNull()
+	}
+	return types.BoolValue(value)
+}
+
+// refreshInt64 is the integer counterpart of refreshString; ok reports whether
+// OPNsense returned a value at all.
+func refreshInt64(prior types.Int64, value int64, ok bool, def int64) types.Int64 {
+	if !ok || (prior.IsNull() && value == def) {
+		return types.Int64Null()
+	}
+	return types.Int64Value(value)
+}
+
+// refreshStringList returns the list to store for an order-insensitive list
+// attribute. The prior ordering is kept when it holds the same elements, and
+// a null attribute stays null while OPNsense reports no elements.
+func refreshStringList(ctx context.Context, prior types.List, values []string, diags *diag.Diagnostics) types.List {
+	if prior.IsNull() && len(values) == 0 {
+		return types.ListNull(types.StringType)
+	}
+	if !prior.IsNull() && !prior.IsUnknown() {
+		var priorValues []string
+		diags.Append(prior.ElementsAs(ctx, &priorValues, false)...)
+		if sameElements(priorValues, values) {
+			return prior
+		}
+	}
+	list, d := types.ListValueFrom(ctx, types.StringType, values)
+	diags.Append(d...)
+	return list
+}
+
+// sameElements reports whether a and b hold the same strings, ignoring order.
+func sameElements(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int, len(a))
+	for _, s := range a {
+		counts[s]++
+	}
+	for _, s := range b {
+		counts[s]--
+		if counts[s] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveSequence fills in the sequence OPNsense assigned to the item at
+// endpoint when the practitioner left it unset, so the computed attribute is
+// known after apply.
+func resolveSequence(ctx context.Context, client *Client, endpoint, key string, sequence *types.Int64, diags *diag.Diagnostics) {
+	if !sequence.IsUnknown() {
+		return
+	}
+
+	*sequence = types.Int64Null()
+	item, err := client.GetItem(ctx, endpoint, key)
+	if err != nil {
+		diags.AddWarning("Unable to read rule sequence", err.Error())
+		return
+	}
+	if item != nil {
+		if value, ok := int64Value(item["sequence"]); ok {
+			*sequence = types.Int64Value(value)
+		}
+	}
+}
+
+// uuidPattern matches the UUIDs OPNsense gives its items.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// isUUID reports whether s has the form of an OPNsense item UUID, which
+// lets import IDs fall back to a lookup by name or description.
+func isUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
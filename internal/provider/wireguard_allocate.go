@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+)
+
+// maxAllocationScan bounds the search for a free address in large (IPv6)
+// tunnel networks.
+const maxAllocationScan = 1 << 16
+
+// allocateWireguardAddress picks the first free host address in the tunnel
+// network of server, skipping the server's own address and every address
+// already assigned to a peer other than self. The caller must hold
+// c.allocations until the peer using the address has been saved, or two
+// peers created in parallel may get the same one.
+func (c *Client) allocateWireguardAddress(ctx context.Context, serverID, self string) (string, error) {
+	server, err := c.GetItem(ctx, "wireguard/server/get_server/"+serverID, "server")
+	if err != nil {
+		return "", err
+	}
+	if server == nil {
+		return "", fmt.Errorf("WireGuard server %q not found", serverID)
+	}
+
+	var network netip.Prefix
+	var used []netip.Prefix
+	for _, addr := range splitList(stringValue(server["tunneladdress"])) {
+		prefix, err := netip.ParsePrefix(addr)
+		if err != nil {
+			continue
+		}
+		used = append(used, netip.PrefixFrom(prefix.Addr(), prefix.Addr().BitLen()))
+		if !network.IsValid() {
+			network = prefix.Masked()
+		}
+	}
+	if !network.IsValid() {
+		return "", fmt.Errorf("WireGuard server %q has no tunnel address to allocate from", serverID)
+	}
+
+	peers, err := c.Search(ctx, "wireguard/client/search_client", nil)
+	if err != nil {
+		return "", err
+	}
+	for _, peer := range peers {
+		if stringValue(peer["uuid"]) == self {
+			continue
+		}
+		for _, addr := range splitList(stringValue(peer["tunneladdress"])) {
+			prefix, err := netip.ParsePrefix(addr)
+			if err != nil {
+				if ip, err := netip.ParseAddr(addr); err == nil {
+					used = append(used, netip.PrefixFrom(ip, ip.BitLen()))
+				}
+				continue
+			}
+			// Routed networks as wide as the tunnel itself (site-to-site
+			// peers, 0.0.0.0/0) do not occupy tunnel addresses.
+			if prefix.Bits() > network.Bits() && prefix.Overlaps(network) {
+				used = append(used, prefix.Masked())
+			}
+		}
+	}
+
+	addr, ok := nextFreeAddress(network, used)
+	if !ok {
+		return "", fmt.Errorf("no free address left in %s", network)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()).String(), nil
+}
+
+// nextFreeAddress returns the lowest host address of network not contained
+// in any of used. The network and, for IPv4, broadcast addresses are never
+// returned.
+func nextFreeAddress(network netip.Prefix, used []netip.Prefix) (netip.Addr, bool) {
+	addr := network.Addr().Next()
+	for i := 0; i < maxAllocationScan && network.Contains(addr); i++ {
+		next := addr.Next()
+		if addr.Is4() && !network.Contains(next) {
+			break
+		}
+
+		free := true
+		for _, u := range used {
+			if u.Contains(addr) {
+				free = false
+				break
+			}
+		}
+		if free {
+			return addr, true
+		}
+		addr = next
+	}
+	return netip.Addr{}, false
+}
@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"context"
+	"crypto/ecdh"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readWireguardKeyFile reads a base64 WireGuard private key such as the
+// output of "wg genkey".
+func readWireguardKeyFile(name string) (string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	key := strings.TrimSpace(string(data))
+	if _, err := wireguardPublicKey(key); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return key, nil
+}
+
+// wireguardPublicKey derives the public key of a base64 private key.
+func wireguardPublicKey(privateKey string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil || len(raw) != 32 {
+		return "", errors.New("not a base64 encoded 32 byte WireGuard private key")
+	}
+	key, err := ecdh.X25519().NewPrivateKey(raw)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()), nil
+}
+
+// generateWireguardKeyPair asks OPNsense to generate a keypair.
+func (c *Client) generateWireguardKeyPair(ctx context.Context) (string, string, error) {
+	var result struct {
+		Status  string `json:"status"`
+		PubKey  string `json:"pubkey"`
+		PrivKey string `json:"privkey"`
+	}
+	if err := c.Get(ctx, "wireguard/server/key_pair", &result); err != nil {
+		return "", "", err
+	}
+	if result.PrivKey == "" || result.PubKey == "" {
+		return "", "", fmt.Errorf("wireguard/server/key_pair returned no keys (status %q)", result.Status)
+	}
+	return result.PrivKey, result.PubKey, nil
+}
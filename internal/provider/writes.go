@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Scopes accepted by the write_lock_scope provider attribute.
+const (
+	writeScopeGlobal    = "global"
+	writeScopeSubsystem = "subsystem"
+)
+
+// defaultMaxConcurrentWrites serializes writes: OPNsense rewrites config.xml
+// on every change and concurrent writers can lose each other's updates.
+const defaultMaxConcurrentWrites = 1
+
+// isMutating reports whether a request changes the configuration. Reads
+// (GET, search and get endpoints) are never throttled.
+func isMutating(method, endpoint string) bool {
+	if method == http.MethodGet {
+		return false
+	}
+
+	parts := strings.Split(strings.Trim(endpoint, "/"), "/")
+	if len(parts) < 3 {
+		return true
+	}
+	action := strings.ToLower(parts[2])
+	return !strings.HasPrefix(action, "search") && !strings.HasPrefix(action, "get")
+}
+
+// writeLimiter bounds the number of mutating requests in flight, either for
+// the whole host or per subsystem (the first path segment of the endpoint,
+// e.g. "firewall", "kea" or "wireguard").
+type writeLimiter struct {
+	limit int
+	scope string
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newWriteLimiter(limit int, scope string) *writeLimiter {
+	if limit <= 0 {
+		limit = defaultMaxConcurrentWrites
+	}
+	if scope == "" {
+		scope = writeScopeGlobal
+	}
+	return &writeLimiter{limit: limit, scope: scope, slots: map[string]chan struct{}{}}
+}
+
+// Acquire waits for a write slot for endpoint. The returned function
+// releases it and must be called exactly once.
+func (l *writeLimiter) Acquire(ctx context.Context, endpoint string) (func(), error) {
+	key := ""
+	if l.scope == writeScopeSubsystem {
+		key, _, _ = strings.Cut(strings.Trim(endpoint, "/"), "/")
+	}
+
+	l.mu.Lock()
+	slots, ok := l.slots[key]
+	if !ok {
+		slots = make(chan struct{}, l.limit)
+		l.slots[key] = slots
+	}
+	l.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}